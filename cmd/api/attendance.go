@@ -37,12 +37,33 @@ func (app *application) createAttendanceHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
+	// The user session has to be one the caller can reach
+	_, err = app.userSessionModel.GetUserSession(attendance.UserSessionID, app.contextGetAccessScope(r))
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("user_session_id", "must refer to an existing user session")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	// Insert the attendance into the database
 	err = app.attendanceModel.Insert(attendance)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateAttendance):
+			v.AddError("date", "attendance has already been recorded for this date")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
+	app.recordAudit(r, data.AuditActionCreate, "attendance", attendance.ID, nil, attendance)
+	app.emitWebhook(r, data.WebhookEventAttendanceRecorded, attendance)
 
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/attendance/%d", attendance.ID))
@@ -67,7 +88,7 @@ func (app *application) displayIndividualAttendanceHandler(w http.ResponseWriter
 		return
 	}
 
-	attendance, err := app.attendanceModel.GetIdividualAttendance(id)
+	attendance, err := app.attendanceModel.GetIdividualAttendance(id, app.contextGetAccessScope(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -98,7 +119,7 @@ func (app *application) updateAttendanceHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	attendance, err := app.attendanceModel.GetIdividualAttendance(id)
+	attendance, err := app.attendanceModel.GetIdividualAttendance(id, app.contextGetAccessScope(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -108,6 +129,7 @@ func (app *application) updateAttendanceHandler(w http.ResponseWriter, r *http.R
 		}
 		return
 	}
+	before := *attendance
 
 	// Read the incoming JSON
 	var incomingData struct {
@@ -141,9 +163,16 @@ func (app *application) updateAttendanceHandler(w http.ResponseWriter, r *http.R
 	// Update the record in the DB
 	err = app.attendanceModel.Update(attendance)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateAttendance):
+			v.AddError("date", "attendance has already been recorded for this date")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
+	app.recordAudit(r, data.AuditActionUpdate, "attendance", attendance.ID, before, attendance)
 
 	// Send a JSON response with the updated record
 	data := envelope{
@@ -156,3 +185,75 @@ func (app *application) updateAttendanceHandler(w http.ResponseWriter, r *http.R
 		return
 	}
 }
+
+// Records a whole day's roll-call for a session in one request
+func (app *application) recordRollCallHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var incomingData struct {
+		Date    string                `json:"date"`
+		Entries []*data.RollCallEntry `json:"entries"`
+	}
+
+	err = app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	date := parseDate(incomingData.Date)
+
+	v := validator.New()
+	data.ValidateRollCall(v, date, incomingData.Entries)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Only sessions the caller can reach
+	_, err = app.sessionModel.Get(id, app.contextGetAccessScope(r), false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	attendances, previous, err := app.attendanceModel.RecordRollCall(id, date, incomingData.Entries)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrNotEnrolled):
+			v.AddError("entries", err.Error())
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	for i, attendance := range attendances {
+		if previous[i] == nil {
+			app.recordAudit(r, data.AuditActionCreate, "attendance", attendance.ID, nil, attendance)
+		} else {
+			app.recordAudit(r, data.AuditActionUpdate, "attendance", attendance.ID, previous[i], attendance)
+		}
+		app.emitWebhook(r, data.WebhookEventAttendanceRecorded, attendance)
+	}
+
+	data := envelope{
+		"attendance": attendances,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -2,9 +2,12 @@ package main
 
 import (
     "bytes"
+    "context"
     "net/http"
     "net/http/httptest"
     "testing"
+
+    "github.com/julienschmidt/httprouter"
 )
 
 func TestCreateAttendanceHandler_BadJSON(t *testing.T) {
@@ -56,4 +59,30 @@ func TestUpdateAttendanceHandler_InvalidID(t *testing.T) {
     if rr.Code != http.StatusNotFound {
         t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
     }
-}
\ No newline at end of file
+}
+
+func TestRecordRollCallHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/session//attendance", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.recordRollCallHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestRecordRollCallHandler_DuplicateEntries(t *testing.T) {
+    // The same trainee twice on one day should be rejected before hitting the DB
+    payload := `{"date": "2025-06-02", "entries": [{"user_session_id": 1, "present": true}, {"user_session_id": 1, "present": false}]}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/session/1/attendance", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.recordRollCallHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
@@ -0,0 +1,207 @@
+// Filename: cmd/api/audit.go
+package main
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+const auditContextKey = contextKey("audit")
+
+// Collects the changes a handler makes so auditWrites can save them once
+// the request has succeeded. skip is set by handlers that must leave no
+// trace of who made the request.
+type auditRecorder struct {
+	entries []*data.AuditEntry
+	skip    bool
+}
+
+// Writes an audit_log entry for every successful create, update and delete.
+// Handlers describe what they changed with recordAudit; requests that
+// don't get an entry worked out from the method and URL instead so that
+// nothing goes unrecorded. Tokens are left out since signing in and out
+// doesn't change any records.
+func (app *application) auditWrites(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := auditAction(r.Method)
+		if action == "" || strings.HasPrefix(r.URL.Path, "/v1/tokens/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &auditRecorder{}
+		r = r.WithContext(context.WithValue(r.Context(), auditContextKey, recorder))
+
+		// reuse the metrics writer to find out how the request went
+		mw := newMetricsResponseWriter(w)
+		next.ServeHTTP(mw, r)
+
+		if mw.statusCode >= http.StatusBadRequest || recorder.skip {
+			return
+		}
+
+		entries := recorder.entries
+		if len(entries) == 0 {
+			entityType, entityID := auditTargetFromPath(r.URL.Path)
+			entries = []*data.AuditEntry{{Action: action, EntityType: entityType, EntityID: entityID}}
+		}
+
+		actorID := app.contextGetUser(r).ID
+		ip := clientIP(r)
+		for _, entry := range entries {
+			entry.ActorID = actorID
+			entry.IPAddress = ip
+		}
+
+		// The change has already been made so a failure here can only be
+		// reported, not undone
+		err := app.auditModel.Insert(entries...)
+		if err != nil {
+			app.logError(r, err)
+		}
+	})
+}
+
+// Describe a change for the audit log. before is nil for new records and
+// after is nil for deleted ones. Does nothing outside auditWrites.
+func (app *application) recordAudit(r *http.Request, action, entityType string, entityID int64, before, after any) {
+	recorder, ok := r.Context().Value(auditContextKey).(*auditRecorder)
+	if !ok {
+		return
+	}
+
+	changes, err := data.AuditDiff(before, after)
+	if err != nil {
+		app.logError(r, err)
+	}
+
+	recorder.entries = append(recorder.entries, &data.AuditEntry{
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Changes:    changes,
+	})
+}
+
+// Leave the request out of the audit log altogether, for changes such as
+// anonymous survey responses where recording the actor would identify them.
+// Does nothing outside auditWrites.
+func (app *application) skipAudit(r *http.Request) {
+	recorder, ok := r.Context().Value(auditContextKey).(*auditRecorder)
+	if !ok {
+		return
+	}
+
+	recorder.skip = true
+}
+
+// The audit action for a request method, or "" for reads
+func auditAction(method string) string {
+	switch method {
+	case http.MethodPost:
+		return data.AuditActionCreate
+	case http.MethodPut, http.MethodPatch:
+		return data.AuditActionUpdate
+	case http.MethodDelete:
+		return data.AuditActionDelete
+	default:
+		return ""
+	}
+}
+
+// Best guess at what a request changed from its URL: the first part of the
+// path after /v1/ and the first number in it, e.g. /v1/regions/3
+func auditTargetFromPath(path string) (string, int64) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, "/v1/"), "/"), "/")
+
+	var id int64
+	for _, part := range parts[1:] {
+		n, err := strconv.ParseInt(part, 10, 64)
+		if err == nil {
+			id = n
+			break
+		}
+	}
+
+	return parts[0], id
+}
+
+// The address the request came from
+func clientIP(r *http.Request) string {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return ip
+}
+
+// Lists audit log entries, newest first
+func (app *application) listAuditHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		ActorID    int64
+		EntityType string
+		EntityID   int64
+		From       time.Time
+		To         time.Time
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+	v := validator.New()
+
+	queryParametersData.ActorID = int64(app.getSingleIntegerParameter(queryParameters, "actor_id", 0, v))
+	queryParametersData.EntityType = app.getSingleQueryParameter(queryParameters, "entity_type", "")
+	queryParametersData.EntityID = int64(app.getSingleIntegerParameter(queryParameters, "entity_id", 0, v))
+	queryParametersData.From = app.getSingleDateParameter(queryParameters, "from", v)
+	queryParametersData.To = app.getSingleDateParameter(queryParameters, "to", v)
+
+	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 20, v)
+	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "-created_at")
+	queryParametersData.Filters.SortSafeList = []string{"id", "created_at", "-id", "-created_at"}
+
+	if !queryParametersData.From.IsZero() && !queryParametersData.To.IsZero() {
+		v.Check(!queryParametersData.To.Before(queryParametersData.From), "to", "must not be before from")
+	}
+
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// to is inclusive so move it to the start of the following day
+	if !queryParametersData.To.IsZero() {
+		queryParametersData.To = queryParametersData.To.AddDate(0, 0, 1)
+	}
+
+	entries, metadata, err := app.auditModel.GetAll(
+		queryParametersData.ActorID,
+		queryParametersData.EntityType,
+		queryParametersData.EntityID,
+		queryParametersData.From,
+		queryParametersData.To,
+		queryParametersData.Filters,
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"audit":     entries,
+		"@metadata": metadata,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,67 @@
+// Filename: cmd/api/audit_test.go
+package main
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestListAuditHandler_InvalidQueryParam(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/audit?actor_id=abc", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listAuditHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestListAuditHandler_ToBeforeFrom(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/audit?from=2025-02-01&to=2025-01-01", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listAuditHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestAuditWrites_Skipped(t *testing.T) {
+    // With nothing in the context to record the actor, auditWrites would
+    // panic if it tried to write an entry
+    handler := testApp.auditWrites(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        testApp.skipAudit(r)
+        w.WriteHeader(http.StatusCreated)
+    }))
+
+    req := httptest.NewRequest(http.MethodPost, "/v1/session/1/evaluation", nil)
+    rr := httptest.NewRecorder()
+
+    handler.ServeHTTP(rr, req)
+
+    if rr.Code != http.StatusCreated {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusCreated, rr.Code, rr.Body.String())
+    }
+}
+
+func TestAuditTargetFromPath(t *testing.T) {
+    tests := []struct {
+        path       string
+        entityType string
+        entityID   int64
+    }{
+        {"/v1/regions/3", "regions", 3},
+        {"/v1/roles/2/permissions/course:write", "roles", 2},
+        {"/v1/users/assign-role", "users", 0},
+    }
+
+    for _, tt := range tests {
+        entityType, entityID := auditTargetFromPath(tt.path)
+        if entityType != tt.entityType || entityID != tt.entityID {
+            t.Errorf("%s: expected %s %d; got %s %d", tt.path, tt.entityType, tt.entityID, entityType, entityID)
+        }
+    }
+}
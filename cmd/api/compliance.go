@@ -0,0 +1,40 @@
+// Filename: cmd/api/compliance.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+)
+
+// Shows an officer's progress against the training required for their
+// current posting and rank
+func (app *application) displayUserComplianceHandler(w http.ResponseWriter, r *http.Request) {
+	// Only officers within the caller's scope
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	compliance, err := app.complianceModel.GetForUser(user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	data := envelope{
+		"compliance": compliance,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,19 @@
+// Filename: cmd/api/compliance_test.go
+package main
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestDisplayUserComplianceHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/users/compliance/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.displayUserComplianceHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
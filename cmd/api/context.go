@@ -12,6 +12,7 @@ import (
 type contextKey string
 
 const userContextKey = contextKey("user")
+const accessScopeContextKey = contextKey("access_scope")
 
 // Update the request context with the user information
 // We return the request context with user-info added
@@ -33,3 +34,20 @@ func (a *application) contextGetUser(r *http.Request) *data.User {
 
 	return user
 }
+
+// Record how far the permission checked for this request reaches
+func (a *application) contextSetAccessScope(r *http.Request, scope *data.AccessScope) *http.Request {
+	ctx := context.WithValue(r.Context(), accessScopeContextKey, scope)
+	return r.WithContext(ctx)
+}
+
+// Retrieve the scope set by requirePermission. Like contextGetUser we panic
+// when it is missing because the route was wired up without a permission.
+func (a *application) contextGetAccessScope(r *http.Request) *data.AccessScope {
+	scope, ok := r.Context().Value(accessScopeContextKey).(*data.AccessScope)
+	if !ok {
+		panic("missing access scope value in request context")
+	}
+
+	return scope
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
@@ -12,8 +13,11 @@ import (
 
 func (app *application) createCourseHandler(w http.ResponseWriter, r *http.Request) {
 	var incomingData struct {
-		Course_Name string `json:"course"`
-		Description string `json:"description"`
+		Course_Name          string `json:"course"`
+		Description          string `json:"description"`
+		MinAttendancePercent *int   `json:"min_attendance_percent"`
+		HoursPerDay          int    `json:"hours_per_day"`
+		ValidityMonths       int    `json:"validity_months"`
 	}
 
 	err := app.readJSON(w, r, &incomingData)
@@ -23,8 +27,14 @@ func (app *application) createCourseHandler(w http.ResponseWriter, r *http.Reque
 	}
 
 	course := &data.Course{
-		Course_Name: incomingData.Course_Name,
-		Description: incomingData.Description,
+		Course_Name:          incomingData.Course_Name,
+		Description:          incomingData.Description,
+		MinAttendancePercent: data.DefaultMinAttendancePercent,
+		HoursPerDay:          incomingData.HoursPerDay,
+		ValidityMonths:       incomingData.ValidityMonths,
+	}
+	if incomingData.MinAttendancePercent != nil {
+		course.MinAttendancePercent = *incomingData.MinAttendancePercent
 	}
 
 	// Validate the course data
@@ -41,6 +51,7 @@ func (app *application) createCourseHandler(w http.ResponseWriter, r *http.Reque
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	app.recordAudit(r, data.AuditActionCreate, "course", course.ID, nil, course)
 
 	headers := make(http.Header)
 	headers.Set("Location", fmt.Sprintf("/v1/courses/%d", course.ID))
@@ -66,7 +77,12 @@ func (app *application) displayCourseHandler(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	course, err := app.courseModel.Get(id)
+	includeDeleted, ok := app.readIncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	course, err := app.courseModel.Get(id, includeDeleted)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -98,7 +114,7 @@ func (app *application) updateCourseHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	course, err := app.courseModel.Get(id)
+	course, err := app.courseModel.Get(id, false)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -108,10 +124,14 @@ func (app *application) updateCourseHandler(w http.ResponseWriter, r *http.Reque
 		}
 		return
 	}
+	before := *course
 
 	var incomingData struct {
-		Course_Name string `json:"course"`
-		Description string `json:"description"`
+		Course_Name          string `json:"course"`
+		Description          string `json:"description"`
+		MinAttendancePercent *int   `json:"min_attendance_percent"`
+		HoursPerDay          *int   `json:"hours_per_day"`
+		ValidityMonths       *int   `json:"validity_months"`
 	}
 
 	err = app.readJSON(w, r, &incomingData)
@@ -127,6 +147,15 @@ func (app *application) updateCourseHandler(w http.ResponseWriter, r *http.Reque
 	if incomingData.Course_Name != "" {
 		course.Course_Name = incomingData.Course_Name
 	}
+	if incomingData.MinAttendancePercent != nil {
+		course.MinAttendancePercent = *incomingData.MinAttendancePercent
+	}
+	if incomingData.HoursPerDay != nil {
+		course.HoursPerDay = *incomingData.HoursPerDay
+	}
+	if incomingData.ValidityMonths != nil {
+		course.ValidityMonths = *incomingData.ValidityMonths
+	}
 
 	// validate the updated course data
 	v := validator.New()
@@ -142,6 +171,7 @@ func (app *application) updateCourseHandler(w http.ResponseWriter, r *http.Reque
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	app.recordAudit(r, data.AuditActionUpdate, "course", course.ID, before, course)
 
 	data := envelope{
 		"course": course,
@@ -165,6 +195,18 @@ func (app *application) deleteCourseHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	// Keep a copy for the audit log
+	course, err := app.courseModel.Get(id, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	err = app.courseModel.Delete(id)
 	if err != nil {
 		switch {
@@ -175,6 +217,8 @@ func (app *application) deleteCourseHandler(w http.ResponseWriter, r *http.Reque
 		}
 		return
 	}
+	app.recordAudit(r, data.AuditActionDelete, "course", course.ID, course, nil)
+
 	// display the quote
 	data := envelope{"message": "course successfully deleted"}
 
@@ -185,6 +229,58 @@ func (app *application) deleteCourseHandler(w http.ResponseWriter, r *http.Reque
 	}
 }
 
+// Bring back a deleted course
+func (app *application) restoreCourseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	before, err := app.courseModel.Get(id, true)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if before.DeletedAt == nil {
+		app.notDeletedResponse(w, r, "course")
+		return
+	}
+
+	err = app.courseModel.Restore(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	course, err := app.courseModel.Get(id, false)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditActionUpdate, "course", course.ID, before, course)
+
+	data := envelope{
+		"course": course,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 // List all Courses
 func (app *application) listCoursesHandler(w http.ResponseWriter, r *http.Request) {
 	var queryParametersData struct {
@@ -206,6 +302,7 @@ func (app *application) listCoursesHandler(w http.ResponseWriter, r *http.Reques
 	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
 	queryParametersData.Sort = app.getSingleQueryParameter(queryParameters, "sort", "id")
 	queryParametersData.Filters.SortSafeList = []string{"id", "course", "-id", "-course"}
+	format := app.readExportFormat(r, v)
 
 	// Check if the filters are valid
 	data.ValidateFilters(v, queryParametersData.Filters)
@@ -214,8 +311,37 @@ func (app *application) listCoursesHandler(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	includeDeleted, ok := app.readIncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	// Send every matching course as a file if one was asked for
+	if format != "" {
+		header := []string{"id", "course", "description", "min_attendance_percent", "hours_per_day", "validity_months"}
+		app.writeExport(w, r, format, "courses", header, queryParametersData.Filters, func(filters data.Filters) ([][]string, data.Metadata, error) {
+			courses, metadata, err := app.courseModel.GetAll(queryParametersData.Course_Name, queryParametersData.Description, includeDeleted, filters)
+			if err != nil {
+				return nil, data.Metadata{}, err
+			}
+			records := make([][]string, 0, len(courses))
+			for _, course := range courses {
+				records = append(records, []string{
+					strconv.FormatInt(course.ID, 10),
+					course.Course_Name,
+					course.Description,
+					strconv.Itoa(course.MinAttendancePercent),
+					strconv.Itoa(course.HoursPerDay),
+					strconv.Itoa(course.ValidityMonths),
+				})
+			}
+			return records, metadata, nil
+		})
+		return
+	}
+
 	// get the list of courses from the database
-	courses, metadata, err := app.courseModel.GetAll(queryParametersData.Course_Name, queryParametersData.Description, queryParametersData.Filters)
+	courses, metadata, err := app.courseModel.GetAll(queryParametersData.Course_Name, queryParametersData.Description, includeDeleted, queryParametersData.Filters)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
@@ -48,6 +48,7 @@ func (app *application) createCoursePostingHandler(w http.ResponseWriter, r *htt
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	app.recordAudit(r, data.AuditActionCreate, "course_posting", coursePosting.ID, nil, coursePosting)
 
 	header := make(http.Header)
 	header.Set("Location", fmt.Sprintf("/v1/course/postings/%d", coursePosting.ID))
@@ -115,6 +116,7 @@ func (app *application) updateCoursePostingHandler(w http.ResponseWriter, r *htt
 		}
 		return
 	}
+	before := *coursePosting
 
 	var incomingData struct {
 		CourseID    int64 `json:"course_id"`
@@ -161,6 +163,7 @@ func (app *application) updateCoursePostingHandler(w http.ResponseWriter, r *htt
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	app.recordAudit(r, data.AuditActionUpdate, "course_posting", coursePosting.ID, before, coursePosting)
 
 	// Send the updated course posting as JSON response
 	data := envelope{
@@ -184,6 +187,18 @@ func (app *application) deleteCoursePostingHandler(w http.ResponseWriter, r *htt
 	}
 
 	// Delete the course posting from the database
+	// Keep a copy for the audit log
+	coursePosting, err := app.coursepostingModel.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	err = app.coursepostingModel.Delete(id)
 	if err != nil {
 		switch {
@@ -194,6 +209,7 @@ func (app *application) deleteCoursePostingHandler(w http.ResponseWriter, r *htt
 		}
 		return
 	}
+	app.recordAudit(r, data.AuditActionDelete, "course_posting", coursePosting.ID, coursePosting, nil)
 	// display the quote
 	data := envelope{"message": "course posting successfully deleted"}
 
@@ -274,6 +290,7 @@ func (app *application) listCoursePostingsHandler(w http.ResponseWriter, r *http
 	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
 	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "id")
 	queryParametersData.Filters.SortSafeList = []string{"id", "course_id", "posting_id", "mandatory", "credithours", "rank_id", "-id", "-course_id", "-posting_id", "-mandatory", "-credithours", "-rank_id"}
+	format := app.readExportFormat(r, v)
 
 	// Check if the filters are valid
 	data.ValidateFilters(v, queryParametersData.Filters)
@@ -282,6 +299,37 @@ func (app *application) listCoursePostingsHandler(w http.ResponseWriter, r *http
 		return
 	}
 
+	// Send every matching course posting as a file if one was asked for
+	if format != "" {
+		header := []string{"id", "course_id", "posting_id", "mandatory", "credithours", "rank_id"}
+		app.writeExport(w, r, format, "course-postings", header, queryParametersData.Filters, func(filters data.Filters) ([][]string, data.Metadata, error) {
+			coursePostings, metadata, err := app.coursepostingModel.GetAll(
+				queryParametersData.CourseID,
+				queryParametersData.PostingID,
+				queryParametersData.Mandatory,
+				queryParametersData.CreditHours,
+				queryParametersData.RankID,
+				filters,
+			)
+			if err != nil {
+				return nil, data.Metadata{}, err
+			}
+			records := make([][]string, 0, len(coursePostings))
+			for _, coursePosting := range coursePostings {
+				records = append(records, []string{
+					strconv.FormatInt(coursePosting.ID, 10),
+					strconv.FormatInt(coursePosting.CourseID, 10),
+					strconv.FormatInt(coursePosting.PostingID, 10),
+					strconv.FormatBool(coursePosting.Mandatory),
+					strconv.FormatInt(coursePosting.CreditHours, 10),
+					strconv.FormatInt(coursePosting.RankID, 10),
+				})
+			}
+			return records, metadata, nil
+		})
+		return
+	}
+
 	// Get the list of course postings from the database
 	coursePostings, metadata, err := app.coursepostingModel.GetAll(
 		queryParametersData.CourseID,
@@ -0,0 +1,242 @@
+// Filename: cmd/api/course_prerequisite.go
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Look up the course named in the URL, sending a 404 if it doesn't exist
+func (app *application) readCourseParam(w http.ResponseWriter, r *http.Request) (*data.Course, bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	course, err := app.courseModel.Get(id, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return course, true
+}
+
+// Lists what a trainee needs before they can take a course
+func (app *application) listCoursePrerequisitesHandler(w http.ResponseWriter, r *http.Request) {
+	course, ok := app.readCourseParam(w, r)
+	if !ok {
+		return
+	}
+
+	prerequisites, err := app.coursePrerequisiteModel.GetAllForCourse(course.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"prerequisites": prerequisites,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Makes a course require another course, or a minimum rank
+func (app *application) addCoursePrerequisiteHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var incomingData struct {
+		RequiredCourseID int64 `json:"required_course_id"`
+		MinRankID        int64 `json:"min_rank_id"`
+	}
+
+	err = app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	prerequisite := &data.CoursePrerequisite{
+		CourseID:         id,
+		RequiredCourseID: incomingData.RequiredCourseID,
+		MinRankID:        incomingData.MinRankID,
+	}
+
+	v := validator.New()
+	data.ValidateCoursePrerequisite(v, prerequisite)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, ok := app.readCourseParam(w, r)
+	if !ok {
+		return
+	}
+
+	// Deleted courses can't be taken so they can't be required either
+	if prerequisite.RequiredCourseID > 0 {
+		_, err = app.courseModel.Get(prerequisite.RequiredCourseID, false)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				v.AddError("required_course_id", "must refer to an existing course")
+				app.failedValidationResponse(w, r, v.Errors)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	err = app.coursePrerequisiteModel.Insert(prerequisite)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrCourseNotFound):
+			v.AddError("required_course_id", "must refer to an existing course")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrRankNotFound):
+			v.AddError("min_rank_id", "must refer to an existing rank")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrPrerequisiteCycle):
+			v.AddError("required_course_id", "already requires this course, directly or through another course")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrDuplicatePrerequisite):
+			app.duplicatePrerequisiteResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.recordAudit(r, data.AuditActionCreate, "course_prerequisite", prerequisite.ID, nil, prerequisite)
+
+	prerequisites, err := app.coursePrerequisiteModel.GetAllForCourse(id)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"prerequisites": prerequisites,
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Removes one of a course's prerequisites
+func (app *application) deleteCoursePrerequisiteHandler(w http.ResponseWriter, r *http.Request) {
+	courseID, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	id, err := strconv.ParseInt(httprouter.ParamsFromContext(r.Context()).ByName("prerequisite_id"), 10, 64)
+	if err != nil || id < 1 {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	err = app.coursePrerequisiteModel.Delete(courseID, id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.recordAudit(r, data.AuditActionDelete, "course_prerequisite", id, nil, nil)
+
+	data := envelope{
+		"message": "prerequisite successfully removed from course",
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Shows whether an officer can take a course and what they are missing.
+// Without a user_id it checks the signed in officer.
+func (app *application) courseEligibilityHandler(w http.ResponseWriter, r *http.Request) {
+	v := validator.New()
+	userID := int64(app.getSingleIntegerParameter(r.URL.Query(), "user_id", 0, v))
+	v.Check(userID >= 0, "user_id", "must not be negative")
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	course, ok := app.readCourseParam(w, r)
+	if !ok {
+		return
+	}
+
+	if userID == 0 {
+		userID = app.contextGetUser(r).ID
+	} else {
+		// Looking up another officer is reading their record, so they have
+		// to be within the caller's users:read scope rather than the
+		// course:read scope the route needs
+		scope, err := app.permissionModel.ScopeForUser(app.contextGetUser(r).ID, "users:read")
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if scope == nil {
+			app.notPermittedResponse(w, r)
+			return
+		}
+
+		_, err = app.userModel.GetByID(userID, scope, false)
+		if err != nil {
+			switch {
+			case errors.Is(err, data.ErrRecordNotFound):
+				app.notFoundResponse(w, r)
+			default:
+				app.serverErrorResponse(w, r, err)
+			}
+			return
+		}
+	}
+
+	eligibility, err := app.coursePrerequisiteModel.CheckEligibility(course.ID, userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"eligibility": eligibility,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
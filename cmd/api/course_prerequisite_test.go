@@ -0,0 +1,85 @@
+// Filename: cmd/api/course_prerequisite_test.go
+package main
+
+import (
+    "bytes"
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/julienschmidt/httprouter"
+)
+
+func TestListCoursePrerequisitesHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/courses/abc/prerequisites", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listCoursePrerequisitesHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestAddCoursePrerequisiteHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/courses/1/prerequisites", bytes.NewBufferString("{bad json"))
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.addCoursePrerequisiteHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestAddCoursePrerequisiteHandler_CourseAndRank(t *testing.T) {
+    payload := `{"required_course_id": 2, "min_rank_id": 3}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/courses/1/prerequisites", bytes.NewBufferString(payload))
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.addCoursePrerequisiteHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestAddCoursePrerequisiteHandler_RequiresItself(t *testing.T) {
+    payload := `{"required_course_id": 1}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/courses/1/prerequisites", bytes.NewBufferString(payload))
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.addCoursePrerequisiteHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDeleteCoursePrerequisiteHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/courses/1/prerequisites/abc", nil)
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}, {Key: "prerequisite_id", Value: "abc"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.deleteCoursePrerequisiteHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCourseEligibilityHandler_InvalidUserID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/courses/1/eligibility?user_id=abc", nil)
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.courseEligibilityHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
@@ -32,6 +32,33 @@ func TestCreateCourseHandler_InvalidData(t *testing.T) {
     }
 }
 
+func TestCreateCourseHandler_InvalidPolicy(t *testing.T) {
+    // attendance percentage must be between 0 and 100
+    payload := `{"course":"First Aid", "description":"Basic first aid", "min_attendance_percent": 120}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/courses", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createCourseHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCreateCourseHandler_NegativeValidity(t *testing.T) {
+    payload := `{"course":"Firearms", "description":"Annual firearms qualification", "validity_months": -12}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/courses", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createCourseHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
 func TestDisplayCourseHandler_InvalidID(t *testing.T) {
     req := httptest.NewRequest(http.MethodGet, "/v1/courses/", nil)
     rr := httptest.NewRecorder()
@@ -75,3 +102,25 @@ func TestListCoursesHandler_InvalidQueryParam(t *testing.T) {
         t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
     }
 }
+
+func TestListCoursesHandler_InvalidIncludeDeleted(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/courses?include_deleted=maybe", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listCoursesHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestRestoreCourseHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/courses//restore", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.restoreCourseHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
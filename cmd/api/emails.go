@@ -0,0 +1,86 @@
+// Filename: cmd/api/emails.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Lists emails in the outbox, newest first, so failed ones can be found
+func (app *application) listEmailsHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		Status string
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+	v := validator.New()
+
+	queryParametersData.Status = app.getSingleQueryParameter(queryParameters, "status", "")
+
+	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 20, v)
+	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "-created_at")
+	queryParametersData.Filters.SortSafeList = []string{"id", "created_at", "-id", "-created_at"}
+
+	if queryParametersData.Status != "" {
+		v.Check(validator.PermittedValue(queryParametersData.Status, data.EmailStatuses...), "status", "must be one of pending, sent or failed")
+	}
+
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	emails, metadata, err := app.emailOutboxModel.GetAll(queryParametersData.Status, queryParametersData.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"emails":    emails,
+		"@metadata": metadata,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Puts a failed email back in the outbox to be sent again
+func (app *application) resendEmailHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	email, err := app.emailOutboxModel.Resend(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrEmailNotFailed):
+			app.emailNotFailedResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.recordAudit(r, data.AuditActionUpdate, "email_outbox", email.ID, nil, email)
+
+	data := envelope{
+		"email": email,
+	}
+
+	err = app.writeJSON(w, http.StatusAccepted, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
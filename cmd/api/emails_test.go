@@ -0,0 +1,45 @@
+// Filename: cmd/api/emails_test.go
+package main
+
+import (
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/julienschmidt/httprouter"
+)
+
+func TestListEmailsHandler_InvalidStatus(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/admin/emails?status=bounced", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listEmailsHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestListEmailsHandler_InvalidSort(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/admin/emails?sort=recipient", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listEmailsHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestResendEmailHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/admin/emails/abc/resend", nil)
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "abc"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.resendEmailHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
@@ -0,0 +1,100 @@
+// Filename: cmd/api/enrolment.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+)
+
+// Lets the signed in officer take a seat in a session, or join its waitlist
+// if it is full
+func (app *application) enrollSessionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	eligibility, err := app.coursePrerequisiteModel.CheckEligibilityForSession(id, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	if !eligibility.Eligible {
+		app.ineligibleResponse(w, r, eligibility)
+		return
+	}
+
+	enrolment, err := app.userSessionModel.Enroll(id, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrAlreadyEnrolled):
+			app.alreadyEnrolledResponse(w, r)
+		case errors.Is(err, data.ErrSessionClosed):
+			app.sessionClosedResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	headers := make(http.Header)
+	if enrolment.Status == data.EnrolmentStatusEnrolled {
+		headers.Set("Location", fmt.Sprintf("/v1/user_session/%d", enrolment.UserSessionID))
+	}
+
+	data := envelope{
+		"enrolment": enrolment,
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, data, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Lets the signed in officer give up their seat or waitlist place. A freed
+// seat goes to the next officer on the waitlist.
+func (app *application) withdrawSessionHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	user := app.contextGetUser(r)
+
+	_, err = app.userSessionModel.Withdraw(id, user.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrSessionClosed):
+			app.sessionClosedResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	data := envelope{
+		"message": "successfully withdrawn from the session",
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
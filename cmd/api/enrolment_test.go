@@ -0,0 +1,30 @@
+// Filename: cmd/api/enrolment_test.go
+package main
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestEnrollSessionHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/session//enroll", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.enrollSessionHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestWithdrawSessionHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/session//enroll", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.withdrawSessionHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
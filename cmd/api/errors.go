@@ -4,6 +4,8 @@ package main
 import (
 	"fmt"
 	"net/http"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
 )
 
 // log an error message
@@ -100,3 +102,76 @@ func (a *application) duplicateRoleResponse(w http.ResponseWriter, r *http.Reque
     message := fmt.Sprintf("User has already been assigned the '%s' role", roleName)
     a.errorResponseJSON(w, r, http.StatusConflict, message)
 }
+
+// send a 409 Conflict when a delete is blocked by records that still reference it
+func (a *application) recordInUseResponse(w http.ResponseWriter, r *http.Request, resource string) {
+	message := fmt.Sprintf("unable to delete the %s because other records still reference it", resource)
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 409 Conflict when an officer is already enrolled or waitlisted for a session
+func (a *application) alreadyEnrolledResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you are already enrolled or on the waitlist for this session"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 409 Conflict when an admin adds a trainee who is already in the session
+func (a *application) traineeAlreadyEnrolledResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the trainee is already enrolled in this session"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 409 Conflict when every seat in a session is taken
+func (a *application) sessionFullResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the session is full, the trainee can join the waitlist by enrolling"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 409 Conflict when a trainee has already rated a session's facilitator
+func (a *application) duplicateRatingResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you have already rated the facilitator of this session"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 409 Conflict when a session is no longer open for enrolment changes
+func (a *application) sessionClosedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "enrolment is only open while a session is planned"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 409 Conflict when asked to restore a record that was never deleted
+func (a *application) notDeletedResponse(w http.ResponseWriter, r *http.Request, resource string) {
+	message := fmt.Sprintf("the %s has not been deleted", resource)
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 409 Conflict when a survey that trainees have answered is changed
+func (a *application) surveyAnsweredResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the survey can't be changed once trainees have responded"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 409 Conflict when a trainee evaluates the same session twice
+func (a *application) duplicateResponseResponse(w http.ResponseWriter, r *http.Request) {
+	message := "you have already evaluated this session"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 409 Conflict when a course already has the prerequisite being added
+func (a *application) duplicatePrerequisiteResponse(w http.ResponseWriter, r *http.Request) {
+	message := "the course already requires that course, or already has a minimum rank"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+// send a 422 listing the prerequisites a trainee hasn't met
+func (a *application) emailNotFailedResponse(w http.ResponseWriter, r *http.Request) {
+	message := "only failed emails can be resent"
+	a.errorResponseJSON(w, r, http.StatusConflict, message)
+}
+
+func (a *application) ineligibleResponse(w http.ResponseWriter, r *http.Request, eligibility *data.Eligibility) {
+	errors := map[string]string{
+		"prerequisites": "missing " + eligibility.MissingSummary(),
+	}
+	a.failedValidationResponse(w, r, errors)
+}
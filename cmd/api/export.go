@@ -0,0 +1,92 @@
+// Filename: cmd/api/export.go
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/export"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Exports are read from the database a page at a time so that the whole
+// result set never has to be held in memory
+const exportPageSize = 100
+
+// Work out if the client wants a file instead of JSON. ?format= takes
+// priority over the Accept header. An empty string means JSON.
+func (app *application) readExportFormat(r *http.Request, v *validator.Validator) string {
+	format := app.getSingleQueryParameter(r.URL.Query(), "format", "")
+	if format != "" {
+		v.Check(validator.PermittedValue(format, "json", export.FormatCSV, export.FormatXLSX), "format", "must be one of json, csv or xlsx")
+		if format == "json" {
+			return ""
+		}
+		return format
+	}
+
+	accept := r.Header.Get("Accept")
+	switch {
+	case strings.Contains(accept, "text/csv"):
+		return export.FormatCSV
+	case strings.Contains(accept, export.ContentTypes[export.FormatXLSX]):
+		return export.FormatXLSX
+	}
+
+	return ""
+}
+
+// Stream every row matched by filters to the client as a csv or xlsx file.
+// nextPage is called with the page to fetch until the last page is reached.
+func (app *application) writeExport(w http.ResponseWriter, r *http.Request, format, name string, header []string, filters data.Filters, nextPage func(data.Filters) ([][]string, data.Metadata, error)) {
+	filters.Page = 1
+	filters.PageSize = exportPageSize
+
+	// Fetch the first page before anything is written so that we can
+	// still send a proper error response if the query fails
+	records, metadata, err := nextPage(filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	filename := fmt.Sprintf("%s-%s.%s", name, time.Now().Format("20060102"), format)
+	w.Header().Set("Content-Type", export.ContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	ew, err := export.New(w, format)
+	if err != nil {
+		app.logError(r, err)
+		return
+	}
+
+	err = ew.Write(header)
+	for err == nil {
+		for _, record := range records {
+			err = ew.Write(record)
+			if err != nil {
+				break
+			}
+		}
+		if err != nil || filters.Page >= metadata.LastPage {
+			break
+		}
+
+		filters.Page++
+		records, metadata, err = nextPage(filters)
+	}
+	if err != nil {
+		// The status line has already gone out, all we can do is log
+		app.logError(r, err)
+		return
+	}
+
+	err = ew.Close()
+	if err != nil {
+		app.logError(r, err)
+	}
+}
@@ -0,0 +1,113 @@
+// Filename: cmd/api/export_test.go
+package main
+
+import (
+    "archive/zip"
+    "bytes"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "strings"
+    "testing"
+
+    "github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+    "github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// fakePages hands back two pages of rows so we can check that writeExport
+// keeps asking until it reaches the last page
+func fakePages(filters data.Filters) ([][]string, data.Metadata, error) {
+    records := [][]string{{"1", "Page " + strconv.Itoa(filters.Page)}}
+    return records, data.Metadata{CurrentPage: filters.Page, LastPage: 2}, nil
+}
+
+func TestWriteExport_CSV(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/courses?format=csv", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.writeExport(rr, req, "csv", "courses", []string{"id", "name"}, data.Filters{}, fakePages)
+
+    if rr.Code != http.StatusOK {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusOK, rr.Code, rr.Body.String())
+    }
+    if !strings.HasPrefix(rr.Header().Get("Content-Type"), "text/csv") {
+        t.Fatalf("expected a text/csv content type; got %q", rr.Header().Get("Content-Type"))
+    }
+
+    expected := "id,name\n1,Page 1\n1,Page 2\n"
+    if rr.Body.String() != expected {
+        t.Fatalf("expected body %q; got %q", expected, rr.Body.String())
+    }
+}
+
+func TestWriteExport_XLSX(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/courses?format=xlsx", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.writeExport(rr, req, "xlsx", "courses", []string{"id", "name"}, data.Filters{}, fakePages)
+
+    if rr.Code != http.StatusOK {
+        t.Fatalf("expected status %d; got %d", http.StatusOK, rr.Code)
+    }
+
+    zr, err := zip.NewReader(bytes.NewReader(rr.Body.Bytes()), int64(rr.Body.Len()))
+    if err != nil {
+        t.Fatalf("expected a valid xlsx (zip) file: %v", err)
+    }
+
+    for _, f := range zr.File {
+        if f.Name != "xl/worksheets/sheet1.xml" {
+            continue
+        }
+        rc, err := f.Open()
+        if err != nil {
+            t.Fatal(err)
+        }
+        sheet, _ := io.ReadAll(rc)
+        rc.Close()
+        if !strings.Contains(string(sheet), "Page 2") {
+            t.Fatalf("expected the worksheet to contain the second page; got %s", sheet)
+        }
+        return
+    }
+    t.Fatal("expected the xlsx file to contain a worksheet")
+}
+
+func TestWriteExport_EscapesFormulas(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/courses?format=csv", nil)
+    rr := httptest.NewRecorder()
+
+    rows := func(data.Filters) ([][]string, data.Metadata, error) {
+        records := [][]string{{"=HYPERLINK(\"http://evil\")", "+1+2", "-3", "@SUM(A1)", "plain"}}
+        return records, data.Metadata{}, nil
+    }
+    testApp.writeExport(rr, req, "csv", "courses", []string{"a", "b", "c", "d", "e"}, data.Filters{}, rows)
+
+    expected := "a,b,c,d,e\n\"'=HYPERLINK(\"\"http://evil\"\")\",'+1+2,-3,'@SUM(A1),plain\n"
+    if rr.Body.String() != expected {
+        t.Fatalf("expected body %q; got %q", expected, rr.Body.String())
+    }
+}
+
+func TestListCoursesHandler_InvalidFormat(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/courses?format=pdf", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listCoursesHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestReadExportFormat_AcceptHeader(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/courses", nil)
+    req.Header.Set("Accept", "text/csv")
+
+    format := testApp.readExportFormat(req, validator.New())
+
+    if format != "csv" {
+        t.Fatalf("expected format %q; got %q", "csv", format)
+    }
+}
@@ -5,14 +5,18 @@ import (
 	"fmt"
 	"net/http"
 	"errors"
+	"strconv"
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
 )
 
+// Rate the facilitator of a session the signed in user took part in
 func (a *application) addFacilitatorRating(w http.ResponseWriter, r *http.Request) { 
     var incomingData struct {
-        UserID int   `json:"user_id"`
-        Rating int   `json:"rating"`
+        UserID    int    `json:"user_id"`
+        SessionID int64  `json:"session_id"`
+        Rating    int    `json:"rating"`
+        Comment   string `json:"comment"`
     }
     
     // Decode JSON body
@@ -23,8 +27,10 @@ func (a *application) addFacilitatorRating(w http.ResponseWriter, r *http.Reques
     }
 
     fr := &data.FacilitatorRating{
-        UserID: int64(incomingData.UserID),
-        Rating: incomingData.Rating,
+        UserID:    int64(incomingData.UserID),
+        SessionID: incomingData.SessionID,
+        Rating:    incomingData.Rating,
+        Comment:   incomingData.Comment,
     }
 
     // Initialize a validator instance
@@ -35,12 +41,30 @@ func (a *application) addFacilitatorRating(w http.ResponseWriter, r *http.Reques
         return
     }
 
+    // Ratings are always given as the signed in user
+    fr.RaterID = a.contextGetUser(r).ID
+
     // Insert into database
     err = a.facilitatorRatingModel.Insert(fr)
     if err != nil {
-        a.serverErrorResponse(w, r, err)
+        switch {
+        case errors.Is(err, data.ErrRecordNotFound):
+            v.AddError("session_id", "must refer to an existing session")
+            a.failedValidationResponse(w, r, v.Errors)
+        case errors.Is(err, data.ErrNotFacilitator):
+            v.AddError("user_id", "must be the facilitator of the session")
+            a.failedValidationResponse(w, r, v.Errors)
+        case errors.Is(err, data.ErrNotEnrolled):
+            v.AddError("session_id", "you can only rate sessions you took part in")
+            a.failedValidationResponse(w, r, v.Errors)
+        case errors.Is(err, data.ErrDuplicateRating):
+            a.duplicateRatingResponse(w, r)
+        default:
+            a.serverErrorResponse(w, r, err)
+        }
         return
     }
+    a.recordAudit(r, data.AuditActionCreate, "facilitator_rating", fr.ID, nil, fr)
  
     // Set Location header
     headers := make(http.Header)
@@ -83,7 +107,8 @@ func (a *application) displayFacilitatorRatingHandler(w http.ResponseWriter, r *
 }
 func (a *application) listFacilitatorRatingHandler(w http.ResponseWriter, r *http.Request) {
     var queryData struct {
-        UserID int
+        UserID    int
+        SessionID int
         data.Filters
     }
 
@@ -92,10 +117,12 @@ func (a *application) listFacilitatorRatingHandler(w http.ResponseWriter, r *htt
 
     // Setup pagination & sorting
     v := validator.New()
+    queryData.SessionID = a.getSingleIntegerParameter(q, "session_id", 0, v) // 0 = all sessions
     queryData.Filters.Page = a.getSingleIntegerParameter(q, "page", 1, v)
     queryData.Filters.PageSize = a.getSingleIntegerParameter(q, "page_size", 10, v)
     queryData.Filters.Sort = a.getSingleQueryParameter(q, "sort", "id")
     queryData.Filters.SortSafeList = []string{"id", "user_id", "rating", "-id", "-user_id", "-rating"}
+    format := a.readExportFormat(r, v)
 
     data.ValidateFilters(v, queryData.Filters)
     if !v.IsEmpty() {
@@ -103,7 +130,30 @@ func (a *application) listFacilitatorRatingHandler(w http.ResponseWriter, r *htt
         return
     }
 
-    ratings, metadata, err := a.facilitatorRatingModel.GetAll(int64(queryData.UserID), queryData.Filters)
+    // Send every matching rating as a file if one was asked for
+    if format != "" {
+        header := []string{"id", "user_id", "session_id", "rating", "comment"}
+        a.writeExport(w, r, format, "facilitator-ratings", header, queryData.Filters, func(filters data.Filters) ([][]string, data.Metadata, error) {
+            ratings, metadata, err := a.facilitatorRatingModel.GetAll(int64(queryData.UserID), int64(queryData.SessionID), filters)
+            if err != nil {
+                return nil, data.Metadata{}, err
+            }
+            records := make([][]string, 0, len(ratings))
+            for _, fr := range ratings {
+                records = append(records, []string{
+                    strconv.FormatInt(fr.ID, 10),
+                    strconv.FormatInt(fr.UserID, 10),
+                    strconv.FormatInt(fr.SessionID, 10),
+                    strconv.Itoa(fr.Rating),
+                    fr.Comment,
+                })
+            }
+            return records, metadata, nil
+        })
+        return
+    }
+
+    ratings, metadata, err := a.facilitatorRatingModel.GetAll(int64(queryData.UserID), int64(queryData.SessionID), queryData.Filters)
     if err != nil {
         a.serverErrorResponse(w, r, err)
         return
@@ -118,3 +168,38 @@ func (a *application) listFacilitatorRatingHandler(w http.ResponseWriter, r *htt
         a.serverErrorResponse(w, r, err)
     }
 }
+
+// Average, count and spread of scores for a facilitator, per course
+func (a *application) facilitatorRatingSummaryHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := a.readIDParam(r)
+    if err != nil {
+        a.notFoundResponse(w, r)
+        return
+    }
+
+    // Facilitators who have since been deleted still have their ratings
+    _, err = a.userModel.GetByID(id, data.NationalScope, true)
+    if err != nil {
+        switch {
+        case errors.Is(err, data.ErrRecordNotFound):
+            a.notFoundResponse(w, r)
+        default:
+            a.serverErrorResponse(w, r, err)
+        }
+        return
+    }
+
+    summary, err := a.facilitatorRatingModel.GetSummary(id)
+    if err != nil {
+        a.serverErrorResponse(w, r, err)
+        return
+    }
+
+    data := envelope{
+        "summary": summary,
+    }
+    err = a.writeJSON(w, http.StatusOK, data, nil)
+    if err != nil {
+        a.serverErrorResponse(w, r, err)
+    }
+}
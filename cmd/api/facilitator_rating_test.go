@@ -52,4 +52,27 @@ func TestListFacilitatorRatingHandler_InvalidQueryParam(t *testing.T) {
     if rr.Code != http.StatusUnprocessableEntity {
         t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
     }
-}
\ No newline at end of file
+}
+func TestAddFacilitatorRating_MissingSession(t *testing.T) {
+    payload := `{"rating": 4, "comment": "Clear and well paced"}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/facilitator-rating", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.addFacilitatorRating(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestFacilitatorRatingSummaryHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/facilitators//ratings/summary", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.facilitatorRatingSummaryHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
@@ -0,0 +1,249 @@
+// Filename: cmd/api/formation.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+func (app *application) createFormationHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		RegionID  int64  `json:"region_id"`
+		Formation string `json:"formation"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	formation := &data.Formation{
+		RegionID:  incomingData.RegionID,
+		Formation: incomingData.Formation,
+	}
+
+	// Validate the formation data
+	v := validator.New()
+	data.ValidateFormation(v, formation)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.formationModel.Insert(formation)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRegionNotFound):
+			v.AddError("region_id", "must reference an existing region")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.recordAudit(r, data.AuditActionCreate, "formation", formation.ID, nil, formation)
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/formations/%d", formation.ID))
+
+	data := envelope{
+		"formation": formation,
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, data, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// Displays a formation
+func (app *application) displayFormationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	formation, err := app.formationModel.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	data := envelope{
+		"formation": formation,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// Edit formation
+func (app *application) updateFormationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	formation, err := app.formationModel.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	before := *formation
+
+	var incomingData struct {
+		RegionID  *int64  `json:"region_id"`
+		Formation *string `json:"formation"`
+	}
+
+	err = app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if incomingData.RegionID != nil {
+		formation.RegionID = *incomingData.RegionID
+	}
+	if incomingData.Formation != nil {
+		formation.Formation = *incomingData.Formation
+	}
+
+	v := validator.New()
+	data.ValidateFormation(v, formation)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.formationModel.Update(formation)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrRegionNotFound):
+			v.AddError("region_id", "must reference an existing region")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.recordAudit(r, data.AuditActionUpdate, "formation", formation.ID, before, formation)
+
+	data := envelope{
+		"formation": formation,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// Delete formation
+func (app *application) deleteFormationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	// Keep a copy for the audit log
+	formation, err := app.formationModel.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.formationModel.Delete(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrRecordInUse):
+			app.recordInUseResponse(w, r, "formation")
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.recordAudit(r, data.AuditActionDelete, "formation", formation.ID, formation, nil)
+
+	data := envelope{"message": "formation successfully deleted"}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// List all formations
+func (app *application) listFormationsHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		RegionID  int64
+		Formation string
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	queryParametersData.RegionID = int64(app.getSingleIntegerParameter(queryParameters, "region_id", 0, v))
+	queryParametersData.Formation = app.getSingleQueryParameter(queryParameters, "formation", "")
+
+	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
+	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "id")
+	queryParametersData.Filters.SortSafeList = []string{"id", "region_id", "formation", "-id", "-region_id", "-formation"}
+
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	formations, metadata, err := app.formationModel.GetAll(queryParametersData.RegionID, queryParametersData.Formation, queryParametersData.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"formations": formations,
+		"@metadata":  metadata,
+	}
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
@@ -0,0 +1,77 @@
+// Filename: cmd/api/formation_test.go
+package main
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestCreateFormationHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/formations", bytes.NewBufferString("{bad json"))
+    rr := httptest.NewRecorder()
+
+    testApp.createFormationHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCreateFormationHandler_InvalidData(t *testing.T) {
+    payload := `{"region_id":0,"formation":""}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/formations", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createFormationHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDisplayFormationHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/formations/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.displayFormationHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestUpdateFormationHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPatch, "/v1/formations/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.updateFormationHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDeleteFormationHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/formations/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.deleteFormationHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestListFormationsHandler_InvalidQueryParam(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/formations?page=notint", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listFormationsHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
@@ -149,19 +149,68 @@ func (app *application) getSingleIntegerParameter(queryParameters url.Values, ke
 	return intValue
 }
 
-// Accept a function and run it in the background also recover from any panic
-func (a *application) background(fn func()) {
-	a.wg.Add(1) // Use a wait group to ensure all goroutines finish before we exit
-	go func() {
-		defer a.wg.Done() // signal goroutine is done
-		defer func() {
-			err := recover()
-			if err != nil {
-				a.logger.Error(fmt.Sprintf("%v", err))
-			}
-		}()
-		fn() // Run the actual function
-	}()
+// Read a YYYY-MM-DD query parameter. A zero time is returned if it is
+// missing or can't be parsed, adding a validation error in the latter case.
+func (app *application) getSingleDateParameter(queryParameters url.Values, key string, v *validator.Validator) time.Time {
+	result := queryParameters.Get(key)
+	if result == "" {
+		return time.Time{}
+	}
+
+	date := parseDate(result)
+	if date.IsZero() {
+		v.AddError(key, "must be a date in YYYY-MM-DD format")
+	}
+
+	return date
+}
+
+// Read a number of days written like 90d (the d is optional). Adds a
+// validation error and returns the default if it can't be parsed.
+func (app *application) getSingleDaysParameter(queryParameters url.Values, key string, defaultValue int, v *validator.Validator) int {
+	result := queryParameters.Get(key)
+	if result == "" {
+		return defaultValue
+	}
+
+	days, err := strconv.Atoi(strings.TrimSuffix(result, "d"))
+	if err != nil {
+		v.AddError(key, "must be a number of days, such as 90d")
+		return defaultValue
+	}
+
+	return days
+}
+
+// Read the include_deleted query parameter. Deleted records are only shown
+// to users holding records:admin. ok is false if an error response has
+// already been sent.
+func (app *application) readIncludeDeleted(w http.ResponseWriter, r *http.Request) (includeDeleted bool, ok bool) {
+	result := r.URL.Query().Get("include_deleted")
+	if result == "" {
+		return false, true
+	}
+
+	includeDeleted, err := strconv.ParseBool(result)
+	if err != nil {
+		app.failedValidationResponse(w, r, map[string]string{"include_deleted": "must be true or false"})
+		return false, false
+	}
+	if !includeDeleted {
+		return false, true
+	}
+
+	hasPerm, err := app.permissionModel.HasForUser(app.contextGetUser(r).ID, "records:admin")
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return false, false
+	}
+	if !hasPerm {
+		app.notPermittedResponse(w, r)
+		return false, false
+	}
+
+	return true, true
 }
 
 // parseDate converts a date string in "YYYY-MM-DD" format to a time.Time object
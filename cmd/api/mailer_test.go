@@ -0,0 +1,133 @@
+// Filename: cmd/api/mailer_test.go
+package main
+
+import (
+    "os"
+    "path/filepath"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+    "github.com/kelseyaban/National-Inservice-Training-Database/internal/mailer"
+)
+
+const testSender = "Training Database <no-reply@example.com>"
+
+func TestNewMailer_UnknownTransport(t *testing.T) {
+    var cfg configuration
+    cfg.mail.transport = "pigeon"
+
+    _, err := newMailer(cfg)
+    if err == nil {
+        t.Fatal("expected an error for an unknown transport")
+    }
+}
+
+func TestNewMailer_SMTPNeedsHost(t *testing.T) {
+    var cfg configuration
+    cfg.mail.transport = "smtp"
+
+    _, err := newMailer(cfg)
+    if err == nil {
+        t.Fatal("expected an error when -smtp-host is missing")
+    }
+}
+
+func TestNewMailer_DevelopmentOnlyTransports(t *testing.T) {
+    for _, transport := range []string{"log", "dir", "memory"} {
+        var cfg configuration
+        cfg.mail.transport = transport
+        cfg.mail.dir = t.TempDir()
+
+        cfg.env = "production"
+        _, err := newMailer(cfg)
+        if err == nil {
+            t.Fatalf("expected the %s transport to be refused outside development", transport)
+        }
+
+        cfg.env = "development"
+        _, err = newMailer(cfg)
+        if err != nil {
+            t.Fatalf("expected the %s transport in development; got %v", transport, err)
+        }
+    }
+}
+
+func TestMemoryMailer_CapturesReminder(t *testing.T) {
+    m := mailer.NewMemory(testSender)
+
+    reminder := &data.Reminder{
+        Kind:        data.NotificationSessionReminder,
+        ReferenceID: 7,
+        FName:       "Ana",
+        Course:      "Firearms Refresher",
+        Venue:       "Belmopan",
+        Role:        data.ReminderRoleTrainee,
+        StartsAt:    time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC),
+    }
+    templateFile, templateData := reminderEmail(reminder)
+
+    err := m.Send("ana@example.com", templateFile, templateData)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    messages := m.Messages()
+    if len(messages) != 1 {
+        t.Fatalf("expected 1 message; got %d", len(messages))
+    }
+    msg := messages[0]
+    if msg.To != "ana@example.com" || msg.From != testSender {
+        t.Fatalf("unexpected addresses to=%q from=%q", msg.To, msg.From)
+    }
+    if !strings.Contains(msg.PlainBody, "Firearms Refresher") {
+        t.Fatalf("expected the course in the body; got %q", msg.PlainBody)
+    }
+
+    m.Reset()
+    if len(m.Messages()) != 0 {
+        t.Fatal("expected no messages after Reset")
+    }
+}
+
+func TestMemoryMailer_UnknownTemplate(t *testing.T) {
+    m := mailer.NewMemory(testSender)
+
+    err := m.Send("ana@example.com", "missing.tmpl", nil)
+    if err == nil {
+        t.Fatal("expected an error for a missing template")
+    }
+    if len(m.Messages()) != 0 {
+        t.Fatal("expected nothing to be captured")
+    }
+}
+
+func TestDirMailer_WritesEML(t *testing.T) {
+    dir := filepath.Join(t.TempDir(), "mail")
+    m, err := mailer.NewDir(dir, testSender)
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    err = m.Send("ana@example.com", "token_password_reset.tmpl", map[string]any{"passwordResetToken": "ABC123"})
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+    if err != nil {
+        t.Fatal(err)
+    }
+    if len(files) != 1 {
+        t.Fatalf("expected 1 .eml file; got %d", len(files))
+    }
+
+    contents, err := os.ReadFile(files[0])
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !strings.Contains(string(contents), "To: ana@example.com") {
+        t.Fatalf("expected a To header; got %s", contents)
+    }
+}
@@ -5,8 +5,10 @@ package main
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"expvar"
 	"flag"
+	"fmt"
 	"log/slog"
 	"os"
 	"runtime"
@@ -16,6 +18,7 @@ import (
 
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/mailer"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/webhook"
 	_ "github.com/lib/pq"
 )
 
@@ -39,6 +42,10 @@ type configuration struct {
 	cors struct {
 		trustedOrigins []string
 	}
+	mail struct {
+		transport string
+		dir       string
+	}
 	smtp struct {
 		host     string
 		port     int
@@ -46,6 +53,29 @@ type configuration struct {
 		password string
 		sender   string
 	}
+	purge struct {
+		retention time.Duration
+	}
+	reminders struct {
+		enabled      bool
+		interval     time.Duration
+		sessionLead  time.Duration
+		expiryWindow time.Duration
+	}
+	outbox struct {
+		interval    time.Duration
+		batchSize   int
+		maxAttempts int
+	}
+	webhooks struct {
+		enabled     bool
+		interval    time.Duration
+		batchSize   int
+		maxAttempts int
+		timeout     time.Duration
+		// how often to look for lapsed qualifications for compliance.failed
+		complianceInterval time.Duration
+	}
 }
 
 // Hold dependencies shared across handlers,
@@ -54,18 +84,31 @@ type application struct {
 	config configuration
 	logger *slog.Logger
 	// quoteModel      data.QuoteModel
-	userModel              data.UserModel
-	courseModel            data.CourseModel
-	mailer                 mailer.Mailer
-	wg                     sync.WaitGroup
-	tokenModel             data.TokenModel
-	permissionModel        data.PermissionModel
-	roleModel              data.RoleModel
-	facilitatorRatingModel data.FacilitatorRatingModel
-	sessionModel           data.SessionModel
-	userSessionModel       data.UserSessionModel
-	coursepostingModel     data.CoursePostingModel
-	attendanceModel        data.AttendanceModel
+	userModel               data.UserModel
+	courseModel             data.CourseModel
+	mailer                  mailer.Mailer
+	wg                      sync.WaitGroup
+	tokenModel              data.TokenModel
+	permissionModel         data.PermissionModel
+	roleModel               data.RoleModel
+	facilitatorRatingModel  data.FacilitatorRatingModel
+	sessionModel            data.SessionModel
+	userSessionModel        data.UserSessionModel
+	coursepostingModel      data.CoursePostingModel
+	attendanceModel         data.AttendanceModel
+	regionModel             data.RegionModel
+	formationModel          data.FormationModel
+	rankModel               data.RankModel
+	postingModel            data.PostingModel
+	complianceModel         data.ComplianceModel
+	transcriptModel         data.TranscriptModel
+	auditModel              data.AuditModel
+	surveyModel             data.SurveyModel
+	coursePrerequisiteModel data.CoursePrerequisiteModel
+	notificationModel       data.NotificationModel
+	emailOutboxModel        data.EmailOutboxModel
+	webhookModel            data.WebhookModel
+	webhookClient           *webhook.Client
 }
 
 // loadConfig reads configuration from command line flags
@@ -86,13 +129,17 @@ func loadConfig() configuration {
 
 	flag.BoolVar(&cfg.limiter.enabled, "limiter-enabled", true, "Enable rate limiter")
 
-	// Flags for SMTP
-	flag.StringVar(&cfg.smtp.host, "smtp-host", "sandbox.smtp.mailtrap.io", "SMTP host")
+	// How emails are sent. Only smtp actually delivers them, the others are
+	// for development and tests and are refused outside -env=development.
+	flag.StringVar(&cfg.mail.transport, "mail-transport", "smtp", "Mail transport (smtp|log|dir|memory)")
+	flag.StringVar(&cfg.mail.dir, "mail-dir", "./tmp/mail", "Directory the dir mail transport writes .eml files to")
+
+	// Flags for SMTP. Credentials have no defaults and must be passed in
+	flag.StringVar(&cfg.smtp.host, "smtp-host", "", "SMTP host")
 	// We have port 25, 465, 587, 2525. If 25 doesn't work choose another
 	flag.IntVar(&cfg.smtp.port, "smtp-port", 2525, "SMTP port")
-	flag.StringVar(&cfg.smtp.username, "smtp-username", "213718fe792166", "SMTP username")
-	// Use your Password value provided by Mailtrap
-	flag.StringVar(&cfg.smtp.password, "smtp-password", "54177abe81857f", "SMTP password")
+	flag.StringVar(&cfg.smtp.username, "smtp-username", "", "SMTP username")
+	flag.StringVar(&cfg.smtp.password, "smtp-password", "", "SMTP password")
 
 	flag.StringVar(&cfg.smtp.sender, "smtp-sender", "Training Datatbase <no-reply@trainingdatabase.nationalinservice.net>", "SMTP sender")
 
@@ -100,6 +147,28 @@ func loadConfig() configuration {
 	flag.IntVar(&cfg.db.maxIdleConns, "db-max-idle-conns", 25, "PostgreSQL max idle connections")
 	flag.DurationVar(&cfg.db.maxIdleTime, "db-max-idle-time", 15*time.Minute, "PostgreSQL max connection idle time")
 
+	// Deleted records can only be purged once they are older than this
+	flag.DurationVar(&cfg.purge.retention, "purge-retention", 90*24*time.Hour, "How long deleted records are kept before they can be purged")
+
+	// Reminder emails for upcoming sessions and expiring qualifications
+	flag.BoolVar(&cfg.reminders.enabled, "reminders-enabled", true, "Send scheduled reminder emails")
+	flag.DurationVar(&cfg.reminders.interval, "reminders-interval", 15*time.Minute, "How often to check for reminders that are due")
+	flag.DurationVar(&cfg.reminders.sessionLead, "reminders-session-lead", 48*time.Hour, "How long before a session starts to remind trainees and the facilitator")
+	flag.DurationVar(&cfg.reminders.expiryWindow, "reminders-expiry-window", 30*24*time.Hour, "How long before a qualification expires to warn the officer")
+
+	// Delivery of queued emails
+	flag.DurationVar(&cfg.outbox.interval, "outbox-interval", 5*time.Second, "How often to send emails waiting in the outbox")
+	flag.IntVar(&cfg.outbox.batchSize, "outbox-batch-size", 20, "Most emails to send from the outbox at a time")
+	flag.IntVar(&cfg.outbox.maxAttempts, "outbox-max-attempts", 8, "Attempts before an email is marked as failed")
+
+	// Delivery of webhook events to subscribed systems
+	flag.BoolVar(&cfg.webhooks.enabled, "webhooks-enabled", true, "Deliver webhook events")
+	flag.DurationVar(&cfg.webhooks.interval, "webhooks-interval", 10*time.Second, "How often to send webhook deliveries that are due")
+	flag.IntVar(&cfg.webhooks.batchSize, "webhooks-batch-size", 20, "Most webhook deliveries to send at a time")
+	flag.IntVar(&cfg.webhooks.maxAttempts, "webhooks-max-attempts", 8, "Attempts before a webhook delivery is marked as failed")
+	flag.DurationVar(&cfg.webhooks.timeout, "webhooks-timeout", 5*time.Second, "How long to wait for a subscriber to respond")
+	flag.DurationVar(&cfg.webhooks.complianceInterval, "webhooks-compliance-interval", 15*time.Minute, "How often to check for lapsed qualifications to send compliance.failed")
+
 	// Allow us to access space-seperted origins.
 	flag.Func("cors-trusted-origins", "Trusted CORS origins (space seperated)",
 		func(val string) error {
@@ -149,6 +218,35 @@ func openDB(settings configuration) (*sql.DB, error) {
 
 }
 
+// newMailer builds the mail transport picked with -mail-transport
+func newMailer(settings configuration) (mailer.Mailer, error) {
+	// The other transports never deliver anything, and log prints tokens,
+	// so they mustn't be used by mistake on a real server
+	switch settings.mail.transport {
+	case "log", "dir", "memory":
+		if settings.env != "development" {
+			return nil, fmt.Errorf("the %s mail transport doesn't deliver email and can only be used with -env=development", settings.mail.transport)
+		}
+	}
+
+	switch settings.mail.transport {
+	case "smtp":
+		if settings.smtp.host == "" {
+			return nil, errors.New("the smtp mail transport needs -smtp-host")
+		}
+		return mailer.NewSMTP(settings.smtp.host, settings.smtp.port,
+			settings.smtp.username, settings.smtp.password, settings.smtp.sender), nil
+	case "log":
+		return mailer.NewLog(os.Stdout, settings.smtp.sender), nil
+	case "dir":
+		return mailer.NewDir(settings.mail.dir, settings.smtp.sender)
+	case "memory":
+		return mailer.NewMemory(settings.smtp.sender), nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q", settings.mail.transport)
+	}
+}
+
 func main() {
 
 	// Initialize configuration
@@ -165,6 +263,13 @@ func main() {
 	// release the database resources before exiting
 	defer db.Close()
 
+	mailTransport, err := newMailer(cfg)
+	if err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	logger.Info("mail transport ready", "transport", cfg.mail.transport)
+
 	logger.Info("database connection pool established")
 	expvar.NewString("version").Set(cfg.version)
 
@@ -188,18 +293,30 @@ func main() {
 		config: cfg,
 		logger: logger,
 		// quoteModel: data.QuoteModel{DB: db},
-		userModel:   data.UserModel{DB: db},
-		courseModel: data.CourseModel{DB: db},
-		mailer: mailer.New(cfg.smtp.host, cfg.smtp.port,
-			cfg.smtp.username, cfg.smtp.password, cfg.smtp.sender),
-		tokenModel:             data.TokenModel{DB: db},
-		permissionModel:        data.PermissionModel{DB: db},
-		roleModel:              data.RoleModel{DB: db},
-		facilitatorRatingModel: data.FacilitatorRatingModel{DB: db},
-		sessionModel:           data.SessionModel{DB: db},
-		userSessionModel:       data.UserSessionModel{DB: db},
-		coursepostingModel:     data.CoursePostingModel{DB: db},
-		attendanceModel:        data.AttendanceModel{DB: db},
+		userModel:               data.UserModel{DB: db},
+		courseModel:             data.CourseModel{DB: db},
+		mailer:                  mailTransport,
+		tokenModel:              data.TokenModel{DB: db},
+		permissionModel:         data.PermissionModel{DB: db},
+		roleModel:               data.RoleModel{DB: db},
+		facilitatorRatingModel:  data.FacilitatorRatingModel{DB: db},
+		sessionModel:            data.SessionModel{DB: db},
+		userSessionModel:        data.UserSessionModel{DB: db},
+		coursepostingModel:      data.CoursePostingModel{DB: db},
+		attendanceModel:         data.AttendanceModel{DB: db},
+		regionModel:             data.RegionModel{DB: db},
+		formationModel:          data.FormationModel{DB: db},
+		rankModel:               data.RankModel{DB: db},
+		postingModel:            data.PostingModel{DB: db},
+		complianceModel:         data.ComplianceModel{DB: db},
+		transcriptModel:         data.TranscriptModel{DB: db},
+		auditModel:              data.AuditModel{DB: db},
+		surveyModel:             data.SurveyModel{DB: db},
+		coursePrerequisiteModel: data.CoursePrerequisiteModel{DB: db},
+		notificationModel:       data.NotificationModel{DB: db},
+		emailOutboxModel:        data.EmailOutboxModel{DB: db},
+		webhookModel:            data.WebhookModel{DB: db},
+		webhookClient:           webhook.New(cfg.webhooks.timeout),
 	}
 
 	// Run the application
@@ -0,0 +1,189 @@
+// Filename: cmd/api/me.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// These endpoints let a signed in officer see and manage their own training
+// record. Everything is looked up from the authenticated user so they only
+// need an activated account, not the broad read permissions.
+
+// Load the full record of the signed in user. The user in the request
+// context only carries what authentication needed.
+func (app *application) currentUser(w http.ResponseWriter, r *http.Request) (*data.User, bool) {
+	user, err := app.userModel.GetByID(app.contextGetUser(r).ID, data.NationalScope, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return user, true
+}
+
+// Shows the signed in officer's profile
+func (app *application) showCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.currentUser(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Lets the signed in officer correct their own profile. Regulation number,
+// formation, rank and posting are managed by administrators.
+func (app *application) updateCurrentUserHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		Username *string `json:"username"`
+		FName    *string `json:"fname"`
+		LName    *string `json:"lname"`
+		Email    *string `json:"email"`
+		Gender   *string `json:"gender"`
+		// needed to change the email address, since that is where
+		// password resets are sent
+		CurrentPassword *string `json:"current_password"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	if incomingData.Email != nil {
+		v.Check(incomingData.CurrentPassword != nil && *incomingData.CurrentPassword != "", "current_password", "must be provided to change email")
+	}
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user, ok := app.currentUser(w, r)
+	if !ok {
+		return
+	}
+	before := *user
+
+	// Update fields only if provided
+	if incomingData.Username != nil {
+		user.Username = *incomingData.Username
+	}
+	if incomingData.FName != nil {
+		user.FName = *incomingData.FName
+	}
+	if incomingData.LName != nil {
+		user.LName = *incomingData.LName
+	}
+	if incomingData.Email != nil && *incomingData.Email != user.Email {
+		match, err := user.Password.Matches(*incomingData.CurrentPassword)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+			return
+		}
+		if !match {
+			v.AddError("current_password", "is incorrect")
+			app.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+		user.Email = *incomingData.Email
+	}
+	if incomingData.Gender != nil {
+		user.Gender = *incomingData.Gender
+	}
+
+	data.ValidateUser(v, *user)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.userModel.Update(user)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrDuplicateEmail):
+			v.AddError("email", "a user with this email address already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrDuplicateUsername):
+			v.AddError("username", "a user with this username already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.recordAudit(r, data.AuditActionUpdate, "users", user.ID, before, user)
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user": user}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Lists the sessions the signed in officer has taken part in
+func (app *application) listCurrentUserSessionsHandler(w http.ResponseWriter, r *http.Request) {
+	sessions, err := app.userSessionModel.GetAllForTrainee(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"user_session": sessions}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Lists the signed in officer's attendance across all their sessions
+func (app *application) listCurrentUserAttendanceHandler(w http.ResponseWriter, r *http.Request) {
+	attendance, err := app.attendanceModel.GetAllForTrainee(app.contextGetUser(r).ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"attendance": attendance}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Shows the signed in officer's progress against the training required for
+// their posting and rank
+func (app *application) showCurrentUserComplianceHandler(w http.ResponseWriter, r *http.Request) {
+	compliance, err := app.complianceModel.GetForUser(app.contextGetUser(r).ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.writeJSON(w, http.StatusOK, envelope{"compliance": compliance}, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Sends the signed in officer's transcript as a PDF
+func (app *application) showCurrentUserTranscriptHandler(w http.ResponseWriter, r *http.Request) {
+	app.writeTranscript(w, r, app.contextGetUser(r).ID)
+}
@@ -0,0 +1,46 @@
+// Filename: cmd/api/me_test.go
+package main
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestUpdateCurrentUserHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPatch, "/v1/me", bytes.NewBufferString("{bad json"))
+    rr := httptest.NewRecorder()
+
+    testApp.updateCurrentUserHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestUpdateCurrentUserHandler_UnknownField(t *testing.T) {
+    payload := `{"formation": 2}`
+    req := httptest.NewRequest(http.MethodPatch, "/v1/me", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.updateCurrentUserHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestUpdateCurrentUserHandler_EmailNeedsCurrentPassword(t *testing.T) {
+    payload := `{"email": "new@example.com"}`
+    req := httptest.NewRequest(http.MethodPatch, "/v1/me", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.updateCurrentUserHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
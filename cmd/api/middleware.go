@@ -232,21 +232,25 @@ func (a *application) requireActivatedUser(next http.HandlerFunc) http.HandlerFu
 
 // Checks if the user has the right permissions
 // We send the permission that is expected as an argument
+// The scope of the user's grant is added to the request context so the
+// handler only reaches records in the user's region or formation
 func (a *application) requirePermission(permissionCode string, next http.HandlerFunc) http.HandlerFunc {
 
 	fn := func(w http.ResponseWriter, r *http.Request) {
 		user := a.contextGetUser(r)
-		// get all the permissions associated with the user
-		permissions, err := a.permissionModel.GetAllForUser(user.ID)
+		// how far the user's grants of the permission reach, nil if they
+		// don't hold it at all
+		scope, err := a.permissionModel.ScopeForUser(user.ID, permissionCode)
 		if err != nil {
 			a.serverErrorResponse(w, r, err)
 			return
 		}
-		if !permissions.Include(permissionCode) {
+		if scope == nil {
 			a.notPermittedResponse(w, r)
 			return
 		}
 
+		r = a.contextSetAccessScope(r, scope)
 		next.ServeHTTP(w, r)
 	}
 
@@ -330,8 +334,10 @@ func (mw *metricsResponseWriter) Unwrap() http.ResponseWriter {
 
 func (a *application) assignRoleHandler(w http.ResponseWriter, r *http.Request) {
 	var input struct {
-		UserID  int   `json:"user_id"`
-		RoleIDs []int `json:"role_ids"` // multiple roles
+		UserID      int   `json:"user_id"`
+		RoleIDs     []int `json:"role_ids"`     // multiple roles
+		RegionID    int64 `json:"region_id"`    // optional, limits the roles to a region
+		FormationID int64 `json:"formation_id"` // or to a single formation
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&input)
@@ -345,6 +351,34 @@ func (a *application) assignRoleHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	scope := data.GrantScope{RegionID: input.RegionID, FormationID: input.FormationID}
+	v := validator.New()
+	err = a.checkGrantScope(v, a.contextGetAccessScope(r), scope)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// The caller must hold everything the roles carry where they apply
+	for _, roleID := range input.RoleIDs {
+		codes, err := a.permissionModel.GetAllForRole(int64(roleID))
+		if err == nil {
+			err = a.checkGrantCodes(v, "role_ids", a.contextGetUser(r).ID, scope, codes)
+		}
+		if err != nil {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+		if !v.IsEmpty() {
+			a.failedValidationResponse(w, r, v.Errors)
+			return
+		}
+	}
+
 	// Check for duplicates
 	for _, roleID := range input.RoleIDs {
 		exists, roleName, err := a.roleModel.Exists(input.UserID, roleID)
@@ -359,7 +393,7 @@ func (a *application) assignRoleHandler(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Assign roles (all at once)
-	err = a.roleModel.AddForUserRole(int64(input.UserID), input.RoleIDs...)
+	err = a.roleModel.AddForUserRole(int64(input.UserID), scope, input.RoleIDs...)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -0,0 +1,88 @@
+// Filename: cmd/api/outbox.go
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// How long claimed emails are held for while a batch is sent. A batch that
+// takes longer than this could have its emails sent twice.
+const outboxLease = 10 * time.Minute
+
+// The wait before the first retry of a failed email. It doubles with each
+// attempt up to outboxMaxBackoff.
+const (
+	outboxBaseBackoff = 30 * time.Second
+	outboxMaxBackoff  = 6 * time.Hour
+)
+
+// Start the outbox worker. It sends what is due straight away and then every
+// outbox.interval until ctx is cancelled. It is tracked by app.wg so shutdown
+// waits for a batch in progress to finish.
+func (app *application) startOutbox(ctx context.Context) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		ticker := time.NewTicker(app.config.outbox.interval)
+		defer ticker.Stop()
+
+		for {
+			app.runJob("outbox batch", app.deliverEmails)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Send a batch of due emails. Failures are tried again later with backoff
+// until they run out of attempts, when they are marked as failed.
+func (app *application) deliverEmails() {
+	emails, err := app.emailOutboxModel.ClaimDue(app.config.outbox.batchSize, outboxLease)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	for _, email := range emails {
+		err := app.mailer.Send(email.Recipient, email.Template, email.Data)
+		if err == nil {
+			err = app.emailOutboxModel.MarkSent(email.ID)
+			if err != nil {
+				app.logger.Error(err.Error(), "email_id", email.ID)
+			}
+			continue
+		}
+
+		attempts := email.Attempts + 1
+		deadLetter := attempts >= app.config.outbox.maxAttempts
+		if deadLetter {
+			app.logger.Error("email failed", "email_id", email.ID, "attempts", attempts, "error", err.Error())
+		} else {
+			app.logger.Warn("email not sent, will retry", "email_id", email.ID, "attempts", attempts, "error", err.Error())
+		}
+
+		err = app.emailOutboxModel.RecordFailure(email.ID, err.Error(), time.Now().Add(outboxBackoff(attempts)), deadLetter)
+		if err != nil {
+			app.logger.Error(err.Error(), "email_id", email.ID)
+		}
+	}
+}
+
+// How long to wait before trying an email again after attempt failed
+func outboxBackoff(attempt int) time.Duration {
+	backoff := outboxBaseBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= outboxMaxBackoff {
+			return outboxMaxBackoff
+		}
+	}
+	return backoff
+}
@@ -0,0 +1,27 @@
+// Filename: cmd/api/outbox_test.go
+package main
+
+import (
+    "testing"
+    "time"
+)
+
+func TestOutboxBackoff(t *testing.T) {
+    tests := []struct {
+        attempt int
+        want    time.Duration
+    }{
+        {1, 30 * time.Second},
+        {2, time.Minute},
+        {5, 8 * time.Minute},
+        {10, 4*time.Hour + 16*time.Minute},
+        {11, 6 * time.Hour},
+        {40, 6 * time.Hour},
+    }
+
+    for _, tt := range tests {
+        if got := outboxBackoff(tt.attempt); got != tt.want {
+            t.Fatalf("attempt %d: expected %s; got %s", tt.attempt, tt.want, got)
+        }
+    }
+}
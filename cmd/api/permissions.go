@@ -0,0 +1,238 @@
+// Filename: cmd/api/permissions.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Lists every permission code that can be granted
+func (app *application) listPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	permissions, err := app.permissionModel.GetAll()
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"permissions": permissions,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Check where a grant is meant to apply, making sure the region or
+// formation exists and lies inside the caller's own scope. Problems are
+// added to v and the database is only consulted once everything else is
+// valid.
+func (app *application) checkGrantScope(v *validator.Validator, within *data.AccessScope, scope data.GrantScope) error {
+	data.ValidateGrantScope(v, scope)
+	if !v.IsEmpty() {
+		return nil
+	}
+
+	national := scope.RegionID == 0 && scope.FormationID == 0
+	if national && !within.National {
+		v.AddError("region_id", "must be set unless you have national scope")
+		return nil
+	}
+
+	if scope.RegionID != 0 {
+		_, err := app.regionModel.Get(scope.RegionID)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("region_id", "must refer to an existing region")
+		case err != nil:
+			return err
+		}
+	}
+
+	if scope.FormationID != 0 {
+		_, err := app.formationModel.Get(scope.FormationID)
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("formation_id", "must refer to an existing formation")
+		case err != nil:
+			return err
+		}
+	}
+	if !v.IsEmpty() {
+		return nil
+	}
+
+	covered, err := app.permissionModel.ScopeCovers(within, scope)
+	if err != nil {
+		return err
+	}
+	if !covered {
+		if scope.RegionID != 0 {
+			v.AddError("region_id", "must be inside your own scope")
+		} else {
+			v.AddError("formation_id", "must be inside your own scope")
+		}
+	}
+
+	return nil
+}
+
+// Make sure the caller holds every code they are handing out, everywhere
+// the grant would apply, so nobody can give away more than they have.
+// Problems are added to v under key.
+func (app *application) checkGrantCodes(v *validator.Validator, key string, callerID int64, scope data.GrantScope, codes []string) error {
+	for _, code := range codes {
+		held, err := app.permissionModel.ScopeForUser(callerID, code)
+		if err != nil {
+			return err
+		}
+
+		covered, err := app.permissionModel.ScopeCovers(held, scope)
+		if err != nil {
+			return err
+		}
+		if !covered {
+			v.AddError(key, fmt.Sprintf("cannot grant %s where you do not hold it", code))
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// Send a user's direct grants along with everything they hold once their
+// roles are taken into account
+func (app *application) writeUserPermissions(w http.ResponseWriter, r *http.Request, userID int64) {
+	direct, err := app.permissionModel.GetDirectForUser(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	effective, err := app.permissionModel.GetAllForUser(userID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if effective == nil {
+		effective = data.Permissions{}
+	}
+
+	data := envelope{
+		"user_id":     userID,
+		"direct":      direct,
+		"permissions": effective,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Shows the permissions a user holds
+func (app *application) listUserPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	app.writeUserPermissions(w, r, user.ID)
+}
+
+// Grants one or more permission codes directly to a user
+func (app *application) grantUserPermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		Codes       []string `json:"codes"`
+		RegionID    int64    `json:"region_id"`
+		FormationID int64    `json:"formation_id"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	scope := data.GrantScope{RegionID: incomingData.RegionID, FormationID: incomingData.FormationID}
+
+	v := validator.New()
+	v.Check(len(incomingData.Codes) > 0, "codes", "must contain at least one permission code")
+	err = app.checkGrantScope(v, app.contextGetAccessScope(r), scope)
+	if err == nil && v.IsEmpty() {
+		err = app.checkGrantCodes(v, "codes", app.contextGetUser(r).ID, scope, incomingData.Codes)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	err = app.permissionModel.AddForUser(user.ID, scope, incomingData.Codes...)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrPermissionNotFound):
+			v.AddError("codes", err.Error())
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Info("permissions granted",
+		"user_id", user.ID,
+		"codes", incomingData.Codes,
+		"region_id", scope.RegionID,
+		"formation_id", scope.FormationID,
+		"granted_by", app.contextGetUser(r).ID,
+	)
+
+	app.writeUserPermissions(w, r, user.ID)
+}
+
+// Revokes a permission that was granted directly to a user. Permissions
+// that come from a role have to be removed from the role instead.
+func (app *application) revokeUserPermissionHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	code := httprouter.ParamsFromContext(r.Context()).ByName("code")
+
+	err := app.permissionModel.DeleteForUser(user.ID, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	app.logger.Info("permission revoked",
+		"user_id", user.ID,
+		"code", code,
+		"revoked_by", app.contextGetUser(r).ID,
+	)
+
+	app.writeUserPermissions(w, r, user.ID)
+}
@@ -0,0 +1,73 @@
+// Filename: cmd/api/permissions_test.go
+package main
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+)
+
+func TestListUserPermissionsHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/users/permissions/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listUserPermissionsHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestGrantUserPermissionsHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/users/permissions/1", bytes.NewBufferString("{bad json"))
+    rr := httptest.NewRecorder()
+
+    testApp.grantUserPermissionsHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestGrantUserPermissionsHandler_InvalidData(t *testing.T) {
+    payload := `{"codes": []}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/users/permissions/1", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    req = testApp.contextSetAccessScope(req, data.NationalScope)
+    rr := httptest.NewRecorder()
+
+    testApp.grantUserPermissionsHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestRevokeUserPermissionHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/users/permissions//users:read", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.revokeUserPermissionHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestGrantUserPermissionsHandler_NationalGrantNeedsNationalScope(t *testing.T) {
+    app := newTestApp()
+    payload := `{"codes": ["session:read"]}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/users/permissions/1", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    req = app.contextSetAccessScope(req, &data.AccessScope{FormationIDs: []int64{1}})
+    rr := httptest.NewRecorder()
+
+    app.grantUserPermissionsHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
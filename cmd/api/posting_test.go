@@ -0,0 +1,77 @@
+// Filename: cmd/api/posting_test.go
+package main
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestCreatePostingHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/postings", bytes.NewBufferString("{bad json"))
+    rr := httptest.NewRecorder()
+
+    testApp.createPostingHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCreatePostingHandler_InvalidData(t *testing.T) {
+    payload := `{"posting":""}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/postings", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createPostingHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDisplayPostingHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/postings/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.displayPostingHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestUpdatePostingHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPatch, "/v1/postings/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.updatePostingHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDeletePostingHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/postings/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.deletePostingHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestListPostingsHandler_InvalidQueryParam(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/postings?page=notint", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listPostingsHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
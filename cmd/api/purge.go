@@ -0,0 +1,53 @@
+// Filename: cmd/api/purge.go
+package main
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+)
+
+// Permanently removes users, courses and sessions that have been deleted for
+// longer than the retention period. Anything deleted more recently is left
+// alone so it can still be restored.
+func (app *application) purgeDeletedHandler(w http.ResponseWriter, r *http.Request) {
+	cutoff := time.Now().Add(-app.config.purge.retention)
+
+	// Sessions go first so the counts aren't inflated by sessions removed
+	// along with a purged course
+	sessions, err := app.sessionModel.Purge(cutoff)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	courses, err := app.courseModel.Purge(cutoff)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	users, err := app.userModel.Purge(cutoff)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	purged := map[string]int64{
+		"sessions": sessions,
+		"courses":  courses,
+		"users":    users,
+	}
+	app.recordAudit(r, data.AuditActionDelete, "purge", 0, purged, nil)
+
+	data := envelope{
+		"purged":         purged,
+		"deleted_before": cutoff,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,90 @@
+// Filename: cmd/api/rank_test.go
+package main
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestCreateRankHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/ranks", bytes.NewBufferString("{bad json"))
+    rr := httptest.NewRecorder()
+
+    testApp.createRankHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCreateRankHandler_MissingSeniority(t *testing.T) {
+    payload := `{"title":"Constable - PC"}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/ranks", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createRankHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCreateRankHandler_InvalidData(t *testing.T) {
+    payload := `{"title":""}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/ranks", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createRankHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDisplayRankHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/ranks/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.displayRankHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestUpdateRankHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPatch, "/v1/ranks/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.updateRankHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDeleteRankHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/ranks/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.deleteRankHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestListRanksHandler_InvalidQueryParam(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/ranks?page=notint", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listRanksHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
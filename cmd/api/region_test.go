@@ -0,0 +1,77 @@
+// Filename: cmd/api/region_test.go
+package main
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestCreateRegionHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/regions", bytes.NewBufferString("{bad json"))
+    rr := httptest.NewRecorder()
+
+    testApp.createRegionHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCreateRegionHandler_InvalidData(t *testing.T) {
+    payload := `{"region":""}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/regions", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createRegionHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDisplayRegionHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/regions/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.displayRegionHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestUpdateRegionHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPatch, "/v1/regions/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.updateRegionHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDeleteRegionHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/regions/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.deleteRegionHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestListRegionsHandler_InvalidQueryParam(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/regions?page=notint", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listRegionsHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
@@ -0,0 +1,109 @@
+// Filename: cmd/api/reminders.go
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+)
+
+// How reminder dates are written in emails
+const reminderDateLayout = "Monday 2 January 2006 at 15:04"
+
+// Start the reminder scheduler. It runs straight away and then every
+// reminders.interval until ctx is cancelled. It is tracked by app.wg so
+// shutdown waits for a run in progress to finish.
+func (app *application) startReminders(ctx context.Context) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		ticker := time.NewTicker(app.config.reminders.interval)
+		defer ticker.Stop()
+
+		for {
+			app.runJob("reminder run", app.sendReminders)
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Run one pass of a background job. A panic is logged and the pass
+// abandoned, so it neither takes the server down nor stops the job's ticker
+// and the next pass goes ahead as usual.
+func (app *application) runJob(name string, job func()) {
+	defer func() {
+		if err := recover(); err != nil {
+			app.logger.Error(fmt.Sprintf("%s failed: %v", name, err))
+		}
+	}()
+
+	job()
+}
+
+// Queue every reminder that is due. Each one is claimed in
+// notifications_sent as its email goes into the outbox so that overlapping or
+// restarted runs can't queue it twice.
+func (app *application) sendReminders() {
+	sessionReminders, err := app.notificationModel.DueSessionReminders(app.config.reminders.sessionLead)
+	if err != nil {
+		app.logger.Error(err.Error())
+	}
+
+	expiryNotices, err := app.notificationModel.DueExpiryNotices(app.config.reminders.expiryWindow)
+	if err != nil {
+		app.logger.Error(err.Error())
+	}
+
+	queued := 0
+	for _, reminder := range append(sessionReminders, expiryNotices...) {
+		templateFile, templateData := reminderEmail(reminder)
+		email := &data.Email{
+			Recipient: reminder.Email,
+			Template:  templateFile,
+			Data:      templateData,
+		}
+
+		ok, err := app.notificationModel.Claim(reminder, email)
+		if err != nil {
+			app.logger.Error(err.Error(), "kind", reminder.Kind, "user_id", reminder.UserID)
+			continue
+		}
+		if ok {
+			queued++
+		}
+	}
+
+	if queued > 0 {
+		app.logger.Info("queued reminders", "count", queued)
+	}
+}
+
+// The template and data for a reminder email
+func reminderEmail(reminder *data.Reminder) (string, map[string]any) {
+	switch reminder.Kind {
+	case data.NotificationQualificationExpiring:
+		return "qualification_expiring.tmpl", map[string]any{
+			"fname":     reminder.FName,
+			"course":    reminder.Course,
+			"expiresAt": reminder.ExpiresAt.Format("2 January 2006"),
+		}
+	default:
+		return "session_reminder.tmpl", map[string]any{
+			"fname":     reminder.FName,
+			"course":    reminder.Course,
+			"venue":     reminder.Venue,
+			"role":      reminder.Role,
+			"sessionID": reminder.ReferenceID,
+			"startsAt":  reminder.StartsAt.Format(reminderDateLayout),
+		}
+	}
+}
@@ -0,0 +1,67 @@
+// Filename: cmd/api/reminders_test.go
+package main
+
+import (
+    "testing"
+    "time"
+
+    "github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+)
+
+func TestReminderEmail_SessionReminder(t *testing.T) {
+    reminder := &data.Reminder{
+        Kind:        data.NotificationSessionReminder,
+        ReferenceID: 7,
+        Role:        data.ReminderRoleFacilitator,
+        StartsAt:    time.Date(2025, 6, 2, 9, 0, 0, 0, time.UTC),
+    }
+
+    templateFile, templateData := reminderEmail(reminder)
+
+    if templateFile != "session_reminder.tmpl" {
+        t.Fatalf("expected session_reminder.tmpl; got %s", templateFile)
+    }
+    if templateData["startsAt"] != "Monday 2 June 2025 at 09:00" {
+        t.Fatalf("unexpected startsAt %v", templateData["startsAt"])
+    }
+    if templateData["sessionID"] != int64(7) {
+        t.Fatalf("expected sessionID 7; got %v", templateData["sessionID"])
+    }
+}
+
+func TestReminderEmail_QualificationExpiring(t *testing.T) {
+    reminder := &data.Reminder{
+        Kind:      data.NotificationQualificationExpiring,
+        Course:    "Firearms",
+        ExpiresAt: time.Date(2025, 9, 30, 16, 0, 0, 0, time.UTC),
+    }
+
+    templateFile, templateData := reminderEmail(reminder)
+
+    if templateFile != "qualification_expiring.tmpl" {
+        t.Fatalf("expected qualification_expiring.tmpl; got %s", templateFile)
+    }
+    if templateData["expiresAt"] != "30 September 2025" {
+        t.Fatalf("unexpected expiresAt %v", templateData["expiresAt"])
+    }
+}
+
+func TestRunJob_RecoversFromPanic(t *testing.T) {
+    runs := 0
+    job := func() {
+        runs++
+        if runs == 1 {
+            panic("boom")
+        }
+    }
+
+    app := newTestApp()
+
+    // the first pass panics, the next one must still run
+    app.runJob("test job", job)
+    app.runJob("test job", job)
+
+    if runs != 2 {
+        t.Fatalf("expected 2 runs; got %d", runs)
+    }
+}
@@ -0,0 +1,149 @@
+// Filename: cmd/api/reports.go
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Compliance roll-up by formation for regional and unit commanders, covering
+// only the formations their reports:read scope reaches
+func (app *application) complianceReportHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		RegionID    int64
+		FormationID int64
+		PostingID   int64
+		RankID      int64
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	queryParametersData.RegionID = int64(app.getSingleIntegerParameter(queryParameters, "region", 0, v))
+	queryParametersData.FormationID = int64(app.getSingleIntegerParameter(queryParameters, "formation", 0, v))
+	queryParametersData.PostingID = int64(app.getSingleIntegerParameter(queryParameters, "posting", 0, v))
+	queryParametersData.RankID = int64(app.getSingleIntegerParameter(queryParameters, "rank", 0, v))
+
+	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 20, v)
+	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "formation")
+	queryParametersData.Filters.SortSafeList = []string{"id", "formation", "-id", "-formation"}
+
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	report, metadata, err := app.complianceModel.GetReport(
+		queryParametersData.RegionID,
+		queryParametersData.FormationID,
+		queryParametersData.PostingID,
+		queryParametersData.RankID,
+		app.contextGetAccessScope(r),
+		queryParametersData.Filters,
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"report":    report,
+		"@metadata": metadata,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+}
+
+// Qualifications that lapse within the next ?within= days (90d by default)
+// so that units can schedule refresher sessions in time. Only officers within
+// the caller's reports:read scope are listed.
+func (app *application) expiringReportHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		RegionID    int64
+		FormationID int64
+		WithinDays  int
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	queryParametersData.RegionID = int64(app.getSingleIntegerParameter(queryParameters, "region", 0, v))
+	queryParametersData.FormationID = int64(app.getSingleIntegerParameter(queryParameters, "formation", 0, v))
+	queryParametersData.WithinDays = app.getSingleDaysParameter(queryParameters, "within", 90, v)
+
+	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 20, v)
+	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "expires_at")
+	queryParametersData.Filters.SortSafeList = []string{"expires_at", "course", "-expires_at", "-course"}
+	format := app.readExportFormat(r, v)
+
+	v.Check(queryParametersData.WithinDays > 0, "within", "must be greater than zero")
+	v.Check(queryParametersData.WithinDays <= 3650, "within", "must not be more than 3650d")
+
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	scope := app.contextGetAccessScope(r)
+
+	// Send every matching qualification as a file if one was asked for
+	if format != "" {
+		header := []string{"user_id", "regulation_number", "fname", "lname", "formation", "course", "expires_at"}
+		app.writeExport(w, r, format, "expiring", header, queryParametersData.Filters, func(filters data.Filters) ([][]string, data.Metadata, error) {
+			qualifications, metadata, err := app.complianceModel.GetExpiring(queryParametersData.RegionID, queryParametersData.FormationID, queryParametersData.WithinDays, scope, filters)
+			if err != nil {
+				return nil, data.Metadata{}, err
+			}
+			records := make([][]string, 0, len(qualifications))
+			for _, qualification := range qualifications {
+				records = append(records, []string{
+					strconv.FormatInt(qualification.UserID, 10),
+					qualification.RegulationNumber,
+					qualification.FName,
+					qualification.LName,
+					qualification.Formation,
+					qualification.Course,
+					qualification.ExpiresAt.Format(time.DateOnly),
+				})
+			}
+			return records, metadata, nil
+		})
+		return
+	}
+
+	qualifications, metadata, err := app.complianceModel.GetExpiring(
+		queryParametersData.RegionID,
+		queryParametersData.FormationID,
+		queryParametersData.WithinDays,
+		scope,
+		queryParametersData.Filters,
+	)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"expiring":  qualifications,
+		"@metadata": metadata,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,52 @@
+// Filename: cmd/api/reports_test.go
+package main
+
+import (
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestComplianceReportHandler_InvalidQueryParam(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/reports/compliance?region=north", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.complianceReportHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestComplianceReportHandler_InvalidSort(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/reports/compliance?sort=officers", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.complianceReportHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestExpiringReportHandler_InvalidWithin(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/reports/expiring?within=3m", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.expiringReportHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestExpiringReportHandler_WithinNotPositive(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/reports/expiring?within=0d", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.expiringReportHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
@@ -43,6 +43,7 @@ func (a *application)createRoleHandler(w http.ResponseWriter, r *http.Request) {
         a.serverErrorResponse(w, r, err)
         return
     }
+    a.recordAudit(r, data.AuditActionCreate, "role", role.ID, nil, role)
  
     // Set a Location header. The path to the newly created role
     headers := make(http.Header)
@@ -110,6 +111,7 @@ func (a *application) updateRoleHandler (w http.ResponseWriter, r *http.Request)
         }
         return 
     }
+    before := *role
 
     
     var incomingData struct {
@@ -142,6 +144,7 @@ func (a *application) updateRoleHandler (w http.ResponseWriter, r *http.Request)
        a.serverErrorResponse(w, r, err)
        return 
    }
+   a.recordAudit(r, data.AuditActionUpdate, "role", role.ID, before, role)
    data := envelope {
                 "role": role,
           }
@@ -161,6 +164,18 @@ func (a *application) deleteRoleHandler (w http.ResponseWriter, r *http.Request)
        return 
    }
 
+   // Keep a copy for the audit log
+   role, err := a.roleModel.Get(id)
+   if err != nil {
+       switch {
+           case errors.Is(err, data.ErrRecordNotFound):
+              a.notFoundResponse(w, r)
+           default:
+              a.serverErrorResponse(w, r, err)
+       }
+       return 
+   }
+
    err = a.roleModel.Delete(id)
 
    if err != nil {
@@ -173,6 +188,8 @@ func (a *application) deleteRoleHandler (w http.ResponseWriter, r *http.Request)
        return 
    }
 
+   a.recordAudit(r, data.AuditActionDelete, "role", role.ID, role, nil)
+
    // display the role
    data := envelope {
     "message": "role successfully deleted",
@@ -294,6 +311,45 @@ func (a *application) updateUserRoleHandler(w http.ResponseWriter, r *http.Reque
         return
     }
 
+    // The new role applies wherever the old one did, so the caller must
+    // hold everything it carries in each of those places
+    scopes, err := a.roleModel.GetScopesForUserRole(int(userID), input.OldRoleID)
+    if err != nil {
+        a.serverErrorResponse(w, r, err)
+        return
+    }
+    if len(scopes) == 0 {
+        a.notFoundResponse(w, r)
+        return
+    }
+
+    codes, err := a.permissionModel.GetAllForRole(int64(input.NewRoleID))
+    if err != nil {
+        a.serverErrorResponse(w, r, err)
+        return
+    }
+
+    v := validator.New()
+    for _, scope := range scopes {
+        err = a.checkGrantScope(v, a.contextGetAccessScope(r), scope)
+        if err != nil {
+            a.serverErrorResponse(w, r, err)
+            return
+        }
+        if !v.IsEmpty() {
+            break
+        }
+        err = a.checkGrantCodes(v, "new_role_id", a.contextGetUser(r).ID, scope, codes)
+        if err != nil {
+            a.serverErrorResponse(w, r, err)
+            return
+        }
+    }
+    if !v.IsEmpty() {
+        a.failedValidationResponse(w, r, v.Errors)
+        return
+    }
+
     // Update the role for the user
     err = a.roleModel.UpdateForUserRole(int(userID), input.OldRoleID, input.NewRoleID)
     if err != nil {
@@ -0,0 +1,153 @@
+// Filename: cmd/api/role_permissions.go
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Look up the role named in the URL, sending a 404 if it doesn't exist
+func (app *application) readRoleParam(w http.ResponseWriter, r *http.Request) (*data.Role, bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	role, err := app.roleModel.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return role, true
+}
+
+// Lists the permissions everyone holding the role is granted
+func (app *application) listRolePermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	role, ok := app.readRoleParam(w, r)
+	if !ok {
+		return
+	}
+
+	permissions, err := app.permissionModel.GetAllForRole(role.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"role":        role,
+		"permissions": permissions,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Grants one or more permission codes to a role. A role can be assigned
+// anywhere, so the caller has to hold each code nationally.
+func (app *application) addRolePermissionsHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		Codes []string `json:"codes"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(len(incomingData.Codes) > 0, "codes", "must contain at least one permission code")
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.checkGrantCodes(v, "codes", app.contextGetUser(r).ID, data.GrantScope{}, incomingData.Codes)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	role, ok := app.readRoleParam(w, r)
+	if !ok {
+		return
+	}
+
+	err = app.permissionModel.AddForRole(role.ID, incomingData.Codes...)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrPermissionNotFound):
+			v.AddError("codes", err.Error())
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	permissions, err := app.permissionModel.GetAllForRole(role.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"role":        role,
+		"permissions": permissions,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Takes a permission code away from a role
+func (app *application) deleteRolePermissionHandler(w http.ResponseWriter, r *http.Request) {
+	role, ok := app.readRoleParam(w, r)
+	if !ok {
+		return
+	}
+
+	code := httprouter.ParamsFromContext(r.Context()).ByName("code")
+
+	err := app.permissionModel.DeleteForRole(role.ID, code)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	data := envelope{
+		"message": "permission successfully removed from role",
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
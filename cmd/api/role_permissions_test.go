@@ -0,0 +1,55 @@
+// Filename: cmd/api/role_permissions_test.go
+package main
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestListRolePermissionsHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/roles//permissions", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.listRolePermissionsHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestAddRolePermissionsHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/roles/1/permissions", bytes.NewBufferString("{bad json"))
+    rr := httptest.NewRecorder()
+
+    testApp.addRolePermissionsHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestAddRolePermissionsHandler_InvalidData(t *testing.T) {
+    payload := `{"codes": []}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/roles/1/permissions", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.addRolePermissionsHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestDeleteRolePermissionHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/roles//permissions/course:read", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.deleteRolePermissionHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
@@ -26,11 +26,33 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPost, "/v1/users", app.registerUserHandler)
 	router.HandlerFunc(http.MethodPut, "/v1/users/activated", app.activateUserHandler)
 	router.HandlerFunc(http.MethodPost, "/v1/tokens/authentication", app.createAuthenticationTokenHandler)
+	router.HandlerFunc(http.MethodPost, "/v1/tokens/password-reset", app.createPasswordResetTokenHandler)
+	router.HandlerFunc(http.MethodPut, "/v1/users/password", app.resetUserPasswordHandler)
+	router.HandlerFunc(http.MethodDelete, "/v1/tokens/authentication", app.requireAuthenticatedUser(app.deleteAuthenticationTokenHandler),)
+	router.HandlerFunc(http.MethodDelete, "/v1/tokens/authentication/all", app.requireAuthenticatedUser(app.deleteAllAuthenticationTokensHandler),)
 
 	router.HandlerFunc(http.MethodPatch, "/v1/users/update/:id", app.requirePermission("users:write", app.requireActivatedUser(app.updateUserHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/users/details", app.requirePermission("users:read", app.requireActivatedUser(app.listUsersHandler)),)
 	router.HandlerFunc(http.MethodDelete, "/v1/users/delete/:id", app.requirePermission("users:write", app.requireActivatedUser(app.deleteUserHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/users/restore/:id", app.requirePermission("records:admin", app.requireActivatedUser(app.restoreUserHandler)),)
 	router.HandlerFunc(http.MethodPatch, "/v1/users/update-password/:id", app.requirePermission("users:write", app.requireActivatedUser(app.updatePasswordHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/users/compliance/:id", app.requirePermission("users:read", app.requireActivatedUser(app.displayUserComplianceHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/users/transcript/:id", app.requirePermission("users:read", app.requireActivatedUser(app.displayUserTranscriptHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/users/tokens/:id", app.requirePermission("users:write", app.requireActivatedUser(app.revokeUserTokensHandler)),)
+
+	// Signed in officer's own record
+	router.HandlerFunc(http.MethodGet, "/v1/me", app.requireActivatedUser(app.showCurrentUserHandler),)
+	router.HandlerFunc(http.MethodPatch, "/v1/me", app.requireActivatedUser(app.updateCurrentUserHandler),)
+	router.HandlerFunc(http.MethodGet, "/v1/me/sessions", app.requireActivatedUser(app.listCurrentUserSessionsHandler),)
+	router.HandlerFunc(http.MethodGet, "/v1/me/attendance", app.requireActivatedUser(app.listCurrentUserAttendanceHandler),)
+	router.HandlerFunc(http.MethodGet, "/v1/me/compliance", app.requireActivatedUser(app.showCurrentUserComplianceHandler),)
+	router.HandlerFunc(http.MethodGet, "/v1/me/transcript", app.requireActivatedUser(app.showCurrentUserTranscriptHandler),)
+
+	// Permissions
+	router.HandlerFunc(http.MethodGet, "/v1/permissions", app.requirePermission("permissions:admin", app.requireActivatedUser(app.listPermissionsHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/users/permissions/:id", app.requirePermission("permissions:admin", app.requireActivatedUser(app.listUserPermissionsHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/users/permissions/:id", app.requirePermission("permissions:admin", app.requireActivatedUser(app.grantUserPermissionsHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/users/permissions/:id/:code", app.requirePermission("permissions:admin", app.requireActivatedUser(app.revokeUserPermissionHandler)),)
 
 	// Roles
 	router.HandlerFunc(http.MethodPost, "/v1/roles", app.requirePermission("role:write", app.requireActivatedUser(app.createRoleHandler)),)
@@ -38,25 +60,37 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodPatch, "/v1/roles/:id", app.requirePermission("role:write", app.requireActivatedUser(app.updateRoleHandler)),)
 	router.HandlerFunc(http.MethodDelete, "/v1/roles/:id", app.requirePermission("role:write", app.requireActivatedUser(app.deleteRoleHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/roles", app.requirePermission("role:read", app.requireActivatedUser(app.listRoleHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/roles/:id/permissions", app.requirePermission("role:read", app.requireActivatedUser(app.listRolePermissionsHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/roles/:id/permissions", app.requirePermission("permissions:admin", app.requireActivatedUser(app.addRolePermissionsHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/roles/:id/permissions/:code", app.requirePermission("permissions:admin", app.requireActivatedUser(app.deleteRolePermissionHandler)),)
 
 	//User Roles
-	router.HandlerFunc(http.MethodPost, "/v1/users/assign-role", app.requirePermission("role:write", app.requireActivatedUser(app.assignRoleHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/users/assign-role", app.requirePermission("permissions:admin", app.requireActivatedUser(app.assignRoleHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/users/user_roles/:id", app.requirePermission("role:read", app.requireActivatedUser(app.getUserRolesHandler)),)
-	router.HandlerFunc(http.MethodPatch, "/v1/users/update-role/:id", app.requirePermission("role:write", app.requireActivatedUser(app.updateUserRoleHandler)),)
-	router.HandlerFunc(http.MethodDelete, "/v1/users/delete-role/:id", app.requirePermission("role:write", app.requireActivatedUser(app.deleteUserRoleHandler)),)
+	router.HandlerFunc(http.MethodPatch, "/v1/users/update-role/:id", app.requirePermission("permissions:admin", app.requireActivatedUser(app.updateUserRoleHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/users/delete-role/:id", app.requirePermission("permissions:admin", app.requireActivatedUser(app.deleteUserRoleHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/users/user_roles", app.requirePermission("role:read", app.requireActivatedUser(app.listUsersWithRolesHandler)),)
 
 	//Facilitator Rating
 	router.HandlerFunc(http.MethodPost, "/v1/facilitator-rating", app.requirePermission("facilitator_rating:write", app.requireActivatedUser(app.addFacilitatorRating)),)
 	router.HandlerFunc(http.MethodGet, "/v1/facilitator-rating/:id", app.requirePermission("facilitator_rating:read", app.requireActivatedUser(app.displayFacilitatorRatingHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/facilitator-rating", app.requirePermission("facilitator_rating:read", app.requireActivatedUser(app.listFacilitatorRatingHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/facilitators/:id/ratings/summary", app.requirePermission("facilitator_rating:read", app.requireActivatedUser(app.facilitatorRatingSummaryHandler)),)
 
 	// Courses
 	router.HandlerFunc(http.MethodPost, "/v1/courses", app.requirePermission("course:write", app.requireActivatedUser(app.createCourseHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/courses/:id", app.requirePermission("course:read", app.requireActivatedUser(app.displayCourseHandler)),)
 	router.HandlerFunc(http.MethodPatch, "/v1/courses/:id", app.requirePermission("course:write", app.requireActivatedUser(app.updateCourseHandler)),)
 	router.HandlerFunc(http.MethodDelete, "/v1/courses/:id", app.requirePermission("course:write", app.requireActivatedUser(app.deleteCourseHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/courses/:id/restore", app.requirePermission("records:admin", app.requireActivatedUser(app.restoreCourseHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/courses", app.requirePermission("course:read", app.requireActivatedUser(app.listCoursesHandler)),)
+	router.HandlerFunc(http.MethodPut, "/v1/courses/:id/survey", app.requirePermission("course:write", app.requireActivatedUser(app.setCourseSurveyHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/survey", app.requirePermission("course:read", app.requireActivatedUser(app.showCourseSurveyHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/evaluations", app.requirePermission("reports:read", app.requireActivatedUser(app.courseEvaluationResultsHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/prerequisites", app.requirePermission("course:read", app.requireActivatedUser(app.listCoursePrerequisitesHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/courses/:id/prerequisites", app.requirePermission("course:write", app.requireActivatedUser(app.addCoursePrerequisiteHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/courses/:id/prerequisites/:prerequisite_id", app.requirePermission("course:write", app.requireActivatedUser(app.deleteCoursePrerequisiteHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/courses/:id/eligibility", app.requirePermission("course:read", app.requireActivatedUser(app.courseEligibilityHandler)),)
 
 	// Course Postings
 	router.HandlerFunc(http.MethodPost, "/v1/course/posting", app.requirePermission("course_posting:write", app.requireActivatedUser(app.createCoursePostingHandler)),)
@@ -70,13 +104,20 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodGet, "/v1/session/:id", app.requirePermission("session:read", app.requireActivatedUser(app.displaySessionHandler)),)
 	router.HandlerFunc(http.MethodPatch, "/v1/session/:id", app.requirePermission("session:write", app.requireActivatedUser(app.updateSessionHandler)),)
 	router.HandlerFunc(http.MethodDelete, "/v1/session/:id", app.requirePermission("session:write", app.requireActivatedUser(app.deleteSessionHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/session/:id/restore", app.requirePermission("records:admin", app.requireActivatedUser(app.restoreSessionHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/session", app.requirePermission("session:read", app.requireActivatedUser(app.listSessionHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/session/:id/enroll", app.requireActivatedUser(app.enrollSessionHandler),)
+	router.HandlerFunc(http.MethodDelete, "/v1/session/:id/enroll", app.requireActivatedUser(app.withdrawSessionHandler),)
+	router.HandlerFunc(http.MethodPost, "/v1/session/:id/evaluation", app.requireActivatedUser(app.submitEvaluationHandler),)
+	router.HandlerFunc(http.MethodGet, "/v1/session/:id/evaluations", app.requirePermission("reports:read", app.requireActivatedUser(app.sessionEvaluationResultsHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/session/:id/attendance", app.requirePermission("attendance:write", app.requireActivatedUser(app.recordRollCallHandler)),)
 
 	//User Session
 	router.HandlerFunc(http.MethodPost, "/v1/user_session", app.requirePermission("user_session:write", app.requireActivatedUser(app.createUserSessionHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/user_session/:id", app.requirePermission("user_session:read", app.requireActivatedUser(app.getUserSessionHandler)),)
 	router.HandlerFunc(http.MethodPatch, "/v1/user_session/:id", app.requirePermission("user_session:write", app.requireActivatedUser(app.updateUserSessionHandler)),)
 	router.HandlerFunc(http.MethodDelete, "/v1/user_session/:id", app.requirePermission("user_session:write", app.requireActivatedUser(app.deleteUserSessionHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/user_session/:id/override", app.requirePermission("user_session:write", app.requireActivatedUser(app.clearUserSessionOverrideHandler)),)
 	router.HandlerFunc(http.MethodGet, "/v1/user_session", app.requirePermission("user_session:read", app.requireActivatedUser(app.listUserSessionHandler)),)
 
 	// Attendance
@@ -84,8 +125,59 @@ func (app *application) routes() http.Handler {
 	router.HandlerFunc(http.MethodGet, "/v1/attendance/:id", app.requirePermission("user_session:read", app.requireActivatedUser(app.displayIndividualAttendanceHandler)),)
 	router.HandlerFunc(http.MethodPatch, "/v1/attendance/:id", app.requirePermission("user_session:write", app.requireActivatedUser(app.updateAttendanceHandler)),)
 
+	// Regions
+	router.HandlerFunc(http.MethodPost, "/v1/regions", app.requirePermission("region:write", app.requireActivatedUser(app.createRegionHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/regions/:id", app.requirePermission("region:read", app.requireActivatedUser(app.displayRegionHandler)),)
+	router.HandlerFunc(http.MethodPatch, "/v1/regions/:id", app.requirePermission("region:write", app.requireActivatedUser(app.updateRegionHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/regions/:id", app.requirePermission("region:write", app.requireActivatedUser(app.deleteRegionHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/regions", app.requirePermission("region:read", app.requireActivatedUser(app.listRegionsHandler)),)
+
+	// Formations
+	router.HandlerFunc(http.MethodPost, "/v1/formations", app.requirePermission("formation:write", app.requireActivatedUser(app.createFormationHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/formations/:id", app.requirePermission("formation:read", app.requireActivatedUser(app.displayFormationHandler)),)
+	router.HandlerFunc(http.MethodPatch, "/v1/formations/:id", app.requirePermission("formation:write", app.requireActivatedUser(app.updateFormationHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/formations/:id", app.requirePermission("formation:write", app.requireActivatedUser(app.deleteFormationHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/formations", app.requirePermission("formation:read", app.requireActivatedUser(app.listFormationsHandler)),)
+
+	// Ranks
+	router.HandlerFunc(http.MethodPost, "/v1/ranks", app.requirePermission("rank:write", app.requireActivatedUser(app.createRankHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/ranks/:id", app.requirePermission("rank:read", app.requireActivatedUser(app.displayRankHandler)),)
+	router.HandlerFunc(http.MethodPatch, "/v1/ranks/:id", app.requirePermission("rank:write", app.requireActivatedUser(app.updateRankHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/ranks/:id", app.requirePermission("rank:write", app.requireActivatedUser(app.deleteRankHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/ranks", app.requirePermission("rank:read", app.requireActivatedUser(app.listRanksHandler)),)
+
+	// Postings
+	router.HandlerFunc(http.MethodPost, "/v1/postings", app.requirePermission("posting:write", app.requireActivatedUser(app.createPostingHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/postings/:id", app.requirePermission("posting:read", app.requireActivatedUser(app.displayPostingHandler)),)
+	router.HandlerFunc(http.MethodPatch, "/v1/postings/:id", app.requirePermission("posting:write", app.requireActivatedUser(app.updatePostingHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/postings/:id", app.requirePermission("posting:write", app.requireActivatedUser(app.deletePostingHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/postings", app.requirePermission("posting:read", app.requireActivatedUser(app.listPostingsHandler)),)
+
+	// Audit log
+	router.HandlerFunc(http.MethodGet, "/v1/audit", app.requirePermission("audit:read", app.requireActivatedUser(app.listAuditHandler)),)
+
+	// Permanently remove deleted records past the retention period
+	router.HandlerFunc(http.MethodPost, "/v1/purge", app.requirePermission("records:admin", app.requireActivatedUser(app.purgeDeletedHandler)),)
+
+	// Email outbox
+	router.HandlerFunc(http.MethodGet, "/v1/admin/emails", app.requirePermission("emails:admin", app.requireActivatedUser(app.listEmailsHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/admin/emails/:id/resend", app.requirePermission("emails:admin", app.requireActivatedUser(app.resendEmailHandler)),)
+
+	// Webhooks
+	router.HandlerFunc(http.MethodGet, "/v1/admin/webhooks", app.requirePermission("webhooks:admin", app.requireActivatedUser(app.listWebhooksHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/admin/webhooks", app.requirePermission("webhooks:admin", app.requireActivatedUser(app.createWebhookHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/admin/webhooks/:id", app.requirePermission("webhooks:admin", app.requireActivatedUser(app.displayWebhookHandler)),)
+	router.HandlerFunc(http.MethodPatch, "/v1/admin/webhooks/:id", app.requirePermission("webhooks:admin", app.requireActivatedUser(app.updateWebhookHandler)),)
+	router.HandlerFunc(http.MethodDelete, "/v1/admin/webhooks/:id", app.requirePermission("webhooks:admin", app.requireActivatedUser(app.deleteWebhookHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/admin/webhooks/:id/deliveries", app.requirePermission("webhooks:admin", app.requireActivatedUser(app.listWebhookDeliveriesHandler)),)
+	router.HandlerFunc(http.MethodPost, "/v1/admin/webhooks/:id/ping", app.requirePermission("webhooks:admin", app.requireActivatedUser(app.pingWebhookHandler)),)
+
+	// Reports
+	router.HandlerFunc(http.MethodGet, "/v1/reports/compliance", app.requirePermission("reports:read", app.requireActivatedUser(app.complianceReportHandler)),)
+	router.HandlerFunc(http.MethodGet, "/v1/reports/expiring", app.requirePermission("reports:read", app.requireActivatedUser(app.expiringReportHandler)),)
+
 	router.Handler(http.MethodGet, "/v1/observability/course/metrics", expvar.Handler())
 
-	return app.metrics(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(router)))))
+	return app.metrics(app.recoverPanic(app.enableCORS(app.rateLimit(app.authenticate(app.auditWrites(router))))))
 	//return app.metrics(app.recoverPanic(app.enableCORS(app.rateLimit(router))))
 }
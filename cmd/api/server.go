@@ -25,6 +25,18 @@ func (app *application) serve() error {
 		ErrorLog:     slog.NewLogLogger(app.logger.Handler(), slog.LevelError),
 	}
 
+	// background jobs stop when this is cancelled
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+
+	if app.config.reminders.enabled {
+		app.startReminders(jobsCtx)
+	}
+	app.startOutbox(jobsCtx)
+	if app.config.webhooks.enabled {
+		app.startWebhooks(jobsCtx)
+	}
+
 	// create a channel to keep track of any errors during the shutdown process
 	shutdownError := make(chan error)
 	// create a goroutine that runs in the background listening
@@ -44,8 +56,9 @@ func (app *application) serve() error {
 		if err != nil {
 			shutdownError <- err
 		}
-		// Wait for background tasks to complete
+		// Stop the scheduler and wait for background tasks to complete
 		app.logger.Info("completing background tasks", "address", srv.Addr)
+		stopJobs()
 		app.wg.Wait()
 		shutdownError <- nil // successful shutdown
 	}()
@@ -4,6 +4,8 @@ import (
     "fmt"
     "net/http"
     "errors"
+    "strconv"
+    "time"
 
     "github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
     "github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
@@ -12,9 +14,14 @@ import (
 //------------------ CREATE ------------------
 func (a *application) createSessionHandler(w http.ResponseWriter, r *http.Request) {
     var incomingData struct {
-        CourseID      int64 `json:"course_id"`
-        FormationID   int64 `json:"formation_id"`
-        FacilitatorID int64 `json:"facilitator_id"`
+        CourseID      int64     `json:"course_id"`
+        FormationID   int64     `json:"formation_id"`
+        FacilitatorID int64     `json:"facilitator_id"`
+        StartsAt      time.Time `json:"starts_at"`
+        EndsAt        time.Time `json:"ends_at"`
+        Venue         string    `json:"venue"`
+        Capacity      int       `json:"capacity"`
+        Status        string    `json:"status"`
     }
 
     err := a.readJSON(w, r, &incomingData)
@@ -27,6 +34,16 @@ func (a *application) createSessionHandler(w http.ResponseWriter, r *http.Reques
         CourseID:      incomingData.CourseID,
         FormationID:   incomingData.FormationID,
         FacilitatorID: incomingData.FacilitatorID,
+        StartsAt:      incomingData.StartsAt,
+        EndsAt:        incomingData.EndsAt,
+        Venue:         incomingData.Venue,
+        Capacity:      incomingData.Capacity,
+        Status:        incomingData.Status,
+    }
+
+    // New sessions are planned unless told otherwise
+    if session.Status == "" {
+        session.Status = data.SessionStatusPlanned
     }
 
     v := validator.New()
@@ -36,11 +53,17 @@ func (a *application) createSessionHandler(w http.ResponseWriter, r *http.Reques
         return
     }
 
+    if !a.checkSessionScope(w, r, v, session) {
+        return
+    }
+
     err = a.sessionModel.Insert(session)
     if err != nil {
         a.serverErrorResponse(w, r, err)
         return
     }
+    a.recordAudit(r, data.AuditActionCreate, "session", session.ID, nil, session)
+    a.emitWebhook(r, data.WebhookEventSessionCreated, session)
 
     headers := make(http.Header)
     headers.Set("Location", fmt.Sprintf("/v1/session/%d", session.ID))
@@ -55,6 +78,17 @@ func (a *application) createSessionHandler(w http.ResponseWriter, r *http.Reques
     }
 }
 
+// Sessions can only be created in, or moved to, a formation the caller
+// manages. Sends a validation error and returns false otherwise.
+func (a *application) checkSessionScope(w http.ResponseWriter, r *http.Request, v *validator.Validator, session *data.Session) bool {
+    if !a.contextGetAccessScope(r).Includes(session.FormationID) {
+        v.AddError("formation_id", "must be a formation within your scope")
+        a.failedValidationResponse(w, r, v.Errors)
+        return false
+    }
+    return true
+}
+
 //------------------ DISPLAY ------------------
 func (a *application) displaySessionHandler(w http.ResponseWriter, r *http.Request) {
     id, err := a.readIDParam(r)
@@ -63,7 +97,12 @@ func (a *application) displaySessionHandler(w http.ResponseWriter, r *http.Reque
         return
     }
 
-    session, err := a.sessionModel.Get(id)
+    includeDeleted, ok := a.readIncludeDeleted(w, r)
+    if !ok {
+        return
+    }
+
+    session, err := a.sessionModel.Get(id, a.contextGetAccessScope(r), includeDeleted)
     if err != nil {
         switch {
         case errors.Is(err, data.ErrRecordNotFound):
@@ -92,7 +131,7 @@ func (a *application) updateSessionHandler(w http.ResponseWriter, r *http.Reques
         return
     }
 
-    session, err := a.sessionModel.Get(id)
+    session, err := a.sessionModel.Get(id, a.contextGetAccessScope(r), false)
     if err != nil {
         switch {
         case errors.Is(err, data.ErrRecordNotFound):
@@ -102,11 +141,17 @@ func (a *application) updateSessionHandler(w http.ResponseWriter, r *http.Reques
         }
         return
     }
+    before := *session
 
     var incomingData struct {
-        CourseID      *int64 `json:"course_id"`
-        FormationID   *int64 `json:"formation_id"`
-        FacilitatorID *int64 `json:"facilitator_id"`
+        CourseID      *int64     `json:"course_id"`
+        FormationID   *int64     `json:"formation_id"`
+        FacilitatorID *int64     `json:"facilitator_id"`
+        StartsAt      *time.Time `json:"starts_at"`
+        EndsAt        *time.Time `json:"ends_at"`
+        Venue         *string    `json:"venue"`
+        Capacity      *int       `json:"capacity"`
+        Status        *string    `json:"status"`
     }
 
     err = a.readJSON(w, r, &incomingData)
@@ -124,6 +169,21 @@ func (a *application) updateSessionHandler(w http.ResponseWriter, r *http.Reques
     if incomingData.FacilitatorID != nil {
         session.FacilitatorID = *incomingData.FacilitatorID
     }
+    if incomingData.StartsAt != nil {
+        session.StartsAt = *incomingData.StartsAt
+    }
+    if incomingData.EndsAt != nil {
+        session.EndsAt = *incomingData.EndsAt
+    }
+    if incomingData.Venue != nil {
+        session.Venue = *incomingData.Venue
+    }
+    if incomingData.Capacity != nil {
+        session.Capacity = *incomingData.Capacity
+    }
+    if incomingData.Status != nil {
+        session.Status = *incomingData.Status
+    }
 
     v := validator.New()
     data.ValidateSession(v, session)
@@ -132,11 +192,16 @@ func (a *application) updateSessionHandler(w http.ResponseWriter, r *http.Reques
         return
     }
 
+    if !a.checkSessionScope(w, r, v, session) {
+        return
+    }
+
     err = a.sessionModel.Update(session)
     if err != nil {
         a.serverErrorResponse(w, r, err)
         return
     }
+    a.recordAudit(r, data.AuditActionUpdate, "session", session.ID, before, session)
 
     data := envelope{
         "session": session,
@@ -156,7 +221,21 @@ func (a *application) deleteSessionHandler(w http.ResponseWriter, r *http.Reques
         return
     }
 
-    err = a.sessionModel.Delete(id)
+    scope := a.contextGetAccessScope(r)
+
+    // Keep a copy for the audit log
+    session, err := a.sessionModel.Get(id, scope, false)
+    if err != nil {
+        switch {
+        case errors.Is(err, data.ErrRecordNotFound):
+            a.notFoundResponse(w, r)
+        default:
+            a.serverErrorResponse(w, r, err)
+        }
+        return
+    }
+
+    err = a.sessionModel.Delete(id, scope)
     if err != nil {
         switch {
         case errors.Is(err, data.ErrRecordNotFound):
@@ -166,6 +245,7 @@ func (a *application) deleteSessionHandler(w http.ResponseWriter, r *http.Reques
         }
         return
     }
+    a.recordAudit(r, data.AuditActionDelete, "session", session.ID, session, nil)
 
     data := envelope{
         "message": "session successfully deleted",
@@ -177,19 +257,89 @@ func (a *application) deleteSessionHandler(w http.ResponseWriter, r *http.Reques
     }
 }
 
+//------------------ RESTORE ------------------
+func (a *application) restoreSessionHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := a.readIDParam(r)
+    if err != nil {
+        a.notFoundResponse(w, r)
+        return
+    }
+
+    scope := a.contextGetAccessScope(r)
+
+    before, err := a.sessionModel.Get(id, scope, true)
+    if err != nil {
+        switch {
+        case errors.Is(err, data.ErrRecordNotFound):
+            a.notFoundResponse(w, r)
+        default:
+            a.serverErrorResponse(w, r, err)
+        }
+        return
+    }
+
+    if before.DeletedAt == nil {
+        a.notDeletedResponse(w, r, "session")
+        return
+    }
+
+    err = a.sessionModel.Restore(id, scope)
+    if err != nil {
+        switch {
+        case errors.Is(err, data.ErrRecordNotFound):
+            a.notFoundResponse(w, r)
+        default:
+            a.serverErrorResponse(w, r, err)
+        }
+        return
+    }
+
+    session, err := a.sessionModel.Get(id, scope, false)
+    if err != nil {
+        a.serverErrorResponse(w, r, err)
+        return
+    }
+    a.recordAudit(r, data.AuditActionUpdate, "session", session.ID, before, session)
+
+    data := envelope{
+        "session": session,
+    }
+
+    err = a.writeJSON(w, http.StatusOK, data, nil)
+    if err != nil {
+        a.serverErrorResponse(w, r, err)
+    }
+}
+
 //------------------ LIST ------------------
 func (a *application) listSessionHandler(w http.ResponseWriter, r *http.Request) {
     var queryParametersData struct {
+        FormationID int64
+        Status      string
+        From        time.Time
+        To          time.Time
         data.Filters
     }
 
     queryParameters := r.URL.Query()
     v := validator.New()
 
+    queryParametersData.FormationID = int64(a.getSingleIntegerParameter(queryParameters, "formation_id", 0, v))
+    queryParametersData.Status = a.getSingleQueryParameter(queryParameters, "status", "")
+    queryParametersData.From = a.getSingleDateParameter(queryParameters, "from", v)
+    queryParametersData.To = a.getSingleDateParameter(queryParameters, "to", v)
     queryParametersData.Filters.Page = a.getSingleIntegerParameter(queryParameters, "page", 1, v)
     queryParametersData.Filters.PageSize = a.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
     queryParametersData.Filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", "id")
-    queryParametersData.Filters.SortSafeList = []string{"id", "-id"}
+    queryParametersData.Filters.SortSafeList = []string{"id", "starts_at", "-id", "-starts_at"}
+    format := a.readExportFormat(r, v)
+
+    if queryParametersData.Status != "" {
+        v.Check(validator.PermittedValue(queryParametersData.Status, data.SessionStatuses...), "status", "must be one of planned, ongoing, completed or cancelled")
+    }
+    if !queryParametersData.From.IsZero() && !queryParametersData.To.IsZero() {
+        v.Check(!queryParametersData.To.Before(queryParametersData.From), "to", "must not be before from")
+    }
 
     data.ValidateFilters(v, queryParametersData.Filters)
     if !v.IsEmpty() {
@@ -197,7 +347,47 @@ func (a *application) listSessionHandler(w http.ResponseWriter, r *http.Request)
         return
     }
 
-    sessions, metadata, err := a.sessionModel.GetAll(queryParametersData.Filters)
+    includeDeleted, ok := a.readIncludeDeleted(w, r)
+    if !ok {
+        return
+    }
+
+    scope := a.contextGetAccessScope(r)
+
+    // to is inclusive so move it to the start of the following day
+    if !queryParametersData.To.IsZero() {
+        queryParametersData.To = queryParametersData.To.AddDate(0, 0, 1)
+    }
+
+    // Send every session as a file if one was asked for
+    if format != "" {
+        header := []string{"id", "course_id", "formation_id", "facilitator_id", "starts_at", "ends_at", "venue", "capacity", "status", "created_at"}
+        a.writeExport(w, r, format, "sessions", header, queryParametersData.Filters, func(filters data.Filters) ([][]string, data.Metadata, error) {
+            sessions, metadata, err := a.sessionModel.GetAll(queryParametersData.FormationID, queryParametersData.Status, queryParametersData.From, queryParametersData.To, includeDeleted, filters, scope)
+            if err != nil {
+                return nil, data.Metadata{}, err
+            }
+            records := make([][]string, 0, len(sessions))
+            for _, session := range sessions {
+                records = append(records, []string{
+                    strconv.FormatInt(session.ID, 10),
+                    strconv.FormatInt(session.CourseID, 10),
+                    strconv.FormatInt(session.FormationID, 10),
+                    strconv.FormatInt(session.FacilitatorID, 10),
+                    session.StartsAt.Format(time.RFC3339),
+                    session.EndsAt.Format(time.RFC3339),
+                    session.Venue,
+                    strconv.Itoa(session.Capacity),
+                    session.Status,
+                    session.CreatedAt.Format(time.RFC3339),
+                })
+            }
+            return records, metadata, nil
+        })
+        return
+    }
+
+    sessions, metadata, err := a.sessionModel.GetAll(queryParametersData.FormationID, queryParametersData.Status, queryParametersData.From, queryParametersData.To, includeDeleted, queryParametersData.Filters, scope)
     if err != nil {
         a.serverErrorResponse(w, r, err)
         return
@@ -5,6 +5,8 @@ import (
     "net/http"
     "net/http/httptest"
     "testing"
+
+    "github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
 )
 
 func TestCreateSessionHandler_BadJSON(t *testing.T) {
@@ -64,6 +66,17 @@ func TestDeleteSessionHandler_InvalidID(t *testing.T) {
     }
 }
 
+func TestRestoreSessionHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/session//restore", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.restoreSessionHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
 func TestListSessionHandler_InvalidQueryParam(t *testing.T) {
     req := httptest.NewRequest(http.MethodGet, "/v1/session?page=notint", nil)
     rr := httptest.NewRecorder()
@@ -73,4 +86,43 @@ func TestListSessionHandler_InvalidQueryParam(t *testing.T) {
     if rr.Code != http.StatusUnprocessableEntity {
         t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
     }
-}
\ No newline at end of file
+}
+func TestCreateSessionHandler_EndsBeforeStart(t *testing.T) {
+    payload := `{"course_id":1,"formation_id":1,"facilitator_id":1,"starts_at":"2025-06-02T09:00:00Z","ends_at":"2025-06-01T09:00:00Z"}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/session", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createSessionHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestListSessionHandler_InvalidFilters(t *testing.T) {
+    for _, query := range []string{"status=postponed", "from=01-06-2025", "from=2025-06-02&to=2025-06-01"} {
+        req := httptest.NewRequest(http.MethodGet, "/v1/session?"+query, nil)
+        rr := httptest.NewRecorder()
+
+        testApp.listSessionHandler(rr, req)
+
+        if rr.Code != http.StatusUnprocessableEntity {
+            t.Fatalf("%s: expected status %d; got %d; body=%s", query, http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+        }
+    }
+}
+
+func TestCreateSessionHandler_OutOfScopeFormation(t *testing.T) {
+    payload := `{"course_id":1,"formation_id":2,"facilitator_id":1,"starts_at":"2025-06-01T09:00:00Z","ends_at":"2025-06-02T09:00:00Z"}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/session", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    req = testApp.contextSetAccessScope(req, &data.AccessScope{FormationIDs: []int64{1}})
+    rr := httptest.NewRecorder()
+
+    testApp.createSessionHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
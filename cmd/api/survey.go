@@ -0,0 +1,351 @@
+// Filename: cmd/api/survey.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Create or replace the evaluation survey for a course
+func (app *application) setCourseSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var incomingData struct {
+		Title     string `json:"title"`
+		Questions []struct {
+			Kind     string   `json:"kind"`
+			Prompt   string   `json:"prompt"`
+			Options  []string `json:"options"`
+			Required *bool    `json:"required"`
+		} `json:"questions"`
+	}
+
+	err = app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	survey := &data.Survey{
+		CourseID:  id,
+		Title:     incomingData.Title,
+		Questions: make([]*data.SurveyQuestion, 0, len(incomingData.Questions)),
+	}
+	for _, question := range incomingData.Questions {
+		// questions are required unless they say otherwise
+		required := true
+		if question.Required != nil {
+			required = *question.Required
+		}
+		survey.Questions = append(survey.Questions, &data.SurveyQuestion{
+			Kind:     question.Kind,
+			Prompt:   question.Prompt,
+			Options:  question.Options,
+			Required: required,
+		})
+	}
+
+	v := validator.New()
+	data.ValidateSurvey(v, survey)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	_, err = app.courseModel.Get(id, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	err = app.surveyModel.Set(survey)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrCourseNotFound):
+			app.notFoundResponse(w, r)
+		case errors.Is(err, data.ErrRecordInUse):
+			app.surveyAnsweredResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.recordAudit(r, data.AuditActionUpdate, "survey", survey.ID, nil, survey)
+
+	data := envelope{
+		"survey": survey,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Show the evaluation survey for a course
+func (app *application) showCourseSurveyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	survey, err := app.surveyModel.GetForCourse(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	data := envelope{
+		"survey": survey,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Lets the signed in trainee answer the evaluation for a session they took
+// part in, optionally without their name attached
+func (app *application) submitEvaluationHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	var incomingData struct {
+		Anonymous bool                 `json:"anonymous"`
+		Answers   []*data.SurveyAnswer `json:"answers"`
+	}
+
+	err = app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	v.Check(len(incomingData.Answers) > 0, "answers", "must contain at least one answer")
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	// Trainees can evaluate any session they took part in, wherever it ran
+	session, err := app.sessionModel.Get(id, data.NationalScope, false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	survey, err := app.surveyModel.GetForCourse(session.CourseID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	response := &data.SurveyResponse{
+		SurveyID:  survey.ID,
+		SessionID: session.ID,
+		Answers:   incomingData.Answers,
+	}
+
+	data.ValidateSurveyResponse(v, survey, response)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.surveyModel.InsertResponse(response, app.contextGetUser(r).ID, incomingData.Anonymous)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrNotEnrolled):
+			v.AddError("session", "you can only evaluate sessions you took part in")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrDuplicateResponse):
+			app.duplicateResponseResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	// Only record that the trainee responded, never what they said, and
+	// nothing at all for anonymous responses since the actor and time
+	// would give them away
+	if incomingData.Anonymous {
+		app.skipAudit(r)
+	} else {
+		app.recordAudit(r, data.AuditActionCreate, "survey_submission", session.ID, nil, nil)
+	}
+
+	data := envelope{
+		"response": response,
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Evaluation results across every session of a course
+func (app *application) courseEvaluationResultsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	format := app.readExportFormat(r, v)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	survey, err := app.surveyModel.GetForCourse(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	results, err := app.surveyModel.GetResults(survey, 0)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeSurveyResults(w, r, format, fmt.Sprintf("course-%d-evaluation", id), results)
+}
+
+// Evaluation results for one session
+func (app *application) sessionEvaluationResultsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	v := validator.New()
+	format := app.readExportFormat(r, v)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	session, err := app.sessionModel.Get(id, app.contextGetAccessScope(r), false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	survey, err := app.surveyModel.GetForCourse(session.CourseID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	results, err := app.surveyModel.GetResults(survey, session.ID)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	app.writeSurveyResults(w, r, format, fmt.Sprintf("session-%d-evaluation", id), results)
+}
+
+// Send survey results as JSON, or as a file with one row per answer given
+func (app *application) writeSurveyResults(w http.ResponseWriter, r *http.Request, format, name string, results *data.SurveyResults) {
+	if format == "" {
+		err := app.writeJSON(w, http.StatusOK, envelope{"results": results}, nil)
+		if err != nil {
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	header := []string{"position", "question", "kind", "answered", "average", "answer", "count"}
+	app.writeExport(w, r, format, name, header, data.Filters{}, func(filters data.Filters) ([][]string, data.Metadata, error) {
+		records := [][]string{}
+		for _, question := range results.Questions {
+			average := ""
+			if question.Kind == data.QuestionLikert {
+				average = strconv.FormatFloat(question.Average, 'f', 2, 64)
+			}
+			row := func(answer, count string) []string {
+				return []string{
+					strconv.Itoa(question.Position),
+					question.Prompt,
+					question.Kind,
+					strconv.Itoa(question.Answered),
+					average,
+					answer,
+					count,
+				}
+			}
+
+			values := make([]string, 0, len(question.Counts))
+			for value := range question.Counts {
+				values = append(values, value)
+			}
+			sort.Strings(values)
+			for _, value := range values {
+				records = append(records, row(value, strconv.Itoa(question.Counts[value])))
+			}
+			for _, answer := range question.Answers {
+				records = append(records, row(answer, "1"))
+			}
+			if len(values) == 0 && len(question.Answers) == 0 {
+				records = append(records, row("", "0"))
+			}
+		}
+		return records, data.Metadata{}, nil
+	})
+}
@@ -0,0 +1,84 @@
+// Filename: cmd/api/survey_test.go
+package main
+
+import (
+    "bytes"
+    "context"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/julienschmidt/httprouter"
+)
+
+func TestSetCourseSurveyHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPut, "/v1/courses/abc/survey", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.setCourseSurveyHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestSetCourseSurveyHandler_InvalidSurvey(t *testing.T) {
+    payload := `{"title": "", "questions": [{"kind": "choice", "prompt": "Which module helped most?", "options": ["Only one"]}]}`
+    req := httptest.NewRequest(http.MethodPut, "/v1/courses/1/survey", bytes.NewBufferString(payload))
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.setCourseSurveyHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestSubmitEvaluationHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/session/1/evaluation", bytes.NewBufferString("{bad json"))
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.submitEvaluationHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestSubmitEvaluationHandler_NoAnswers(t *testing.T) {
+    payload := `{"anonymous": true, "answers": []}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/session/1/evaluation", bytes.NewBufferString(payload))
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.submitEvaluationHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCourseEvaluationResultsHandler_InvalidFormat(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/courses/1/evaluations?format=pdf", nil)
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.courseEvaluationResultsHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestSessionEvaluationResultsHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/session/abc/evaluations", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.sessionEvaluationResultsHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
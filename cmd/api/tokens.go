@@ -4,6 +4,7 @@ package main
 import (
 	"errors"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
@@ -75,3 +76,112 @@ func (a *application) createAuthenticationTokenHandler(w http.ResponseWriter, r
 		a.serverErrorResponse(w, r, err)
 	}
 }
+
+// Email a password reset token. The response is the same whether or not the
+// email belongs to an account so it can't be used to find out who is registered.
+func (a *application) createPasswordResetTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		Email string `json:"email"`
+	}
+	err := a.readJSON(w, r, &incomingData)
+	if err != nil {
+		a.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidateEmail(v, incomingData.Email)
+	if !v.IsEmpty() {
+		a.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	env := envelope{
+		"message": "if that email address belongs to an activated account you will receive password reset instructions shortly",
+	}
+
+	user, err := a.userModel.GetByEmail(incomingData.Email)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			err = a.writeJSON(w, http.StatusAccepted, env, nil)
+			if err != nil {
+				a.serverErrorResponse(w, r, err)
+			}
+		default:
+			a.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if user.Activated {
+		// The email goes out through the outbox
+		err = a.tokenModel.NewPasswordReset(user, 45*time.Minute)
+		if err != nil {
+			a.serverErrorResponse(w, r, err)
+			return
+		}
+	}
+
+	err = a.writeJSON(w, http.StatusAccepted, env, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// Sign out by revoking the bearer token sent with this request
+func (a *application) deleteAuthenticationTokenHandler(w http.ResponseWriter, r *http.Request) {
+	// The authenticate middleware has already checked the header is a
+	// well formed Bearer token belonging to this user
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+	err := a.tokenModel.DeleteByPlaintext(data.ScopeAuthentication, token)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{"message": "you have been signed out"}
+	err = a.writeJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// Sign out everywhere by revoking every authentication token the user holds
+func (a *application) deleteAllAuthenticationTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user := a.contextGetUser(r)
+
+	err := a.tokenModel.DeleteAllForUser(data.ScopeAuthentication, user.ID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{"message": "you have been signed out on every device"}
+	err = a.writeJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
+
+// Revoke every token belonging to a user, for example when an officer is
+// suspended or reports a lost device
+func (a *application) revokeUserTokensHandler(w http.ResponseWriter, r *http.Request) {
+	user, ok := a.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	err := a.tokenModel.DeleteAllScopesForUser(user.ID)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+		return
+	}
+
+	env := envelope{"message": "all tokens for the user have been revoked"}
+	err = a.writeJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		a.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,44 @@
+// Filename: cmd/api/tokens_test.go
+package main
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+)
+
+func TestCreatePasswordResetTokenHandler_BadJSON(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/tokens/password-reset", bytes.NewBufferString("{bad json"))
+    rr := httptest.NewRecorder()
+
+    testApp.createPasswordResetTokenHandler(rr, req)
+
+    if rr.Code != http.StatusBadRequest {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusBadRequest, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCreatePasswordResetTokenHandler_InvalidEmail(t *testing.T) {
+    payload := `{"email": "not-an-email"}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/tokens/password-reset", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createPasswordResetTokenHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestRevokeUserTokensHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/users/tokens/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.revokeUserTokensHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
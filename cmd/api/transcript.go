@@ -0,0 +1,140 @@
+// Filename: cmd/api/transcript.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/pdf"
+)
+
+// Page layout for the transcript, in points
+const (
+	transcriptMargin     = 50.0
+	transcriptLineHeight = 16.0
+	transcriptFontSize   = 10.0
+)
+
+// Table columns on the transcript: heading and where the column starts
+var transcriptColumns = []struct {
+	heading string
+	x       float64
+}{
+	{"Course", transcriptMargin},
+	{"Facilitator", 230},
+	{"Hours", 370},
+	{"Grade", 420},
+	{"Attendance", 480},
+}
+
+// Sends an officer's training record as a printable PDF
+func (app *application) displayUserTranscriptHandler(w http.ResponseWriter, r *http.Request) {
+	// Only officers within the caller's scope
+	user, ok := app.readUserParam(w, r)
+	if !ok {
+		return
+	}
+
+	app.writeTranscript(w, r, user.ID)
+}
+
+// Send the officer's transcript as a PDF
+func (app *application) writeTranscript(w http.ResponseWriter, r *http.Request, userID int64) {
+	transcript, err := app.transcriptModel.Get(userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	doc := renderTranscript(transcript, time.Now())
+
+	filename := fmt.Sprintf("transcript-%s.pdf", transcript.RegulationNumber)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+
+	_, err = doc.WriteTo(w)
+	if err != nil {
+		app.logError(r, err)
+	}
+}
+
+// Lay the transcript out as a document. The session table carries on over
+// as many pages as needed, repeating the column headings on each one.
+func renderTranscript(t *data.Transcript, generated time.Time) *pdf.Document {
+	doc := pdf.New()
+	right := pdf.PageWidth - transcriptMargin
+	y := pdf.PageHeight - transcriptMargin
+
+	doc.Text(transcriptMargin, y, 16, true, "National In-Service Training Transcript")
+	y -= transcriptLineHeight * 2
+
+	details := []struct{ label, value string }{
+		{"Name", t.Name},
+		{"Regulation Number", t.RegulationNumber},
+		{"Rank", t.Rank},
+		{"Formation", t.Formation},
+		{"Posting", t.Posting},
+	}
+	for _, detail := range details {
+		doc.Text(transcriptMargin, y, transcriptFontSize, true, detail.label+":")
+		doc.Text(transcriptMargin+110, y, transcriptFontSize, false, detail.value)
+		y -= transcriptLineHeight
+	}
+	y -= transcriptLineHeight
+
+	tableHeader := func() {
+		for _, column := range transcriptColumns {
+			doc.Text(column.x, y, transcriptFontSize, true, column.heading)
+		}
+		doc.Line(transcriptMargin, y-4, right, y-4)
+		y -= transcriptLineHeight + 2
+	}
+	tableHeader()
+
+	if len(t.Entries) == 0 {
+		doc.Text(transcriptMargin, y, transcriptFontSize, false, "No completed training sessions on record.")
+		y -= transcriptLineHeight
+	}
+
+	for _, entry := range t.Entries {
+		if y < transcriptMargin+transcriptLineHeight*2 {
+			doc.AddPage()
+			y = pdf.PageHeight - transcriptMargin
+			tableHeader()
+		}
+
+		attendance := "-"
+		if entry.AttendancePercent != nil {
+			attendance = strconv.FormatFloat(*entry.AttendancePercent, 'f', -1, 64) + "%"
+		}
+
+		values := []string{entry.Course, entry.Facilitator, strconv.FormatInt(entry.Hours, 10), entry.Grade, attendance}
+		for i, column := range transcriptColumns {
+			width := right - column.x
+			if i+1 < len(transcriptColumns) {
+				width = transcriptColumns[i+1].x - column.x - 8
+			}
+			doc.Text(column.x, y, transcriptFontSize, false, pdf.Truncate(values[i], transcriptFontSize, width))
+		}
+		y -= transcriptLineHeight
+	}
+
+	doc.Line(transcriptMargin, y+transcriptLineHeight-4, right, y+transcriptLineHeight-4)
+	y -= 4
+	doc.Text(transcriptMargin, y, transcriptFontSize, true, "Total hours")
+	doc.Text(transcriptColumns[2].x, y, transcriptFontSize, true, strconv.FormatInt(t.TotalHours, 10))
+
+	doc.Text(transcriptMargin, transcriptMargin/2, 8, false, "Generated "+generated.Format("2 January 2006 15:04"))
+
+	return doc
+}
@@ -0,0 +1,44 @@
+// Filename: cmd/api/transcript_test.go
+package main
+
+import (
+    "bytes"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+    "time"
+
+    "github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+)
+
+func TestDisplayUserTranscriptHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/users/transcript/", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.displayUserTranscriptHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
+func TestRenderTranscript_MultiplePages(t *testing.T) {
+    transcript := &data.Transcript{Name: "Jane (Doe)", RegulationNumber: "1234"}
+    for i := 0; i < 80; i++ {
+        transcript.Entries = append(transcript.Entries, &data.TranscriptEntry{Course: "Firearms Safety", Hours: 4})
+    }
+
+    doc := renderTranscript(transcript, time.Now())
+    if doc.PageCount() < 2 {
+        t.Fatalf("expected the table to run over more than one page; got %d", doc.PageCount())
+    }
+
+    var buf bytes.Buffer
+    _, err := doc.WriteTo(&buf)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if !bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")) || !bytes.Contains(buf.Bytes(), []byte(`Jane \(Doe\)`)) {
+        t.Fatalf("unexpected document output")
+    }
+}
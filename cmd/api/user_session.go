@@ -4,6 +4,8 @@ import (
     "fmt"
     "net/http"
     "errors"
+    "strconv"
+    "time"
 
     "github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
     "github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
@@ -17,6 +19,8 @@ func (a *application) createUserSessionHandler(w http.ResponseWriter, r *http.Re
         CreditHoursCompleted int64  `json:"credithours_completed"`
         Grade                string `json:"grade"`
         Feedback             string `json:"feedback"`
+        Completed            bool   `json:"completed"`
+        OverrideReason       string `json:"override_reason"`
     }
 
     err := a.readJSON(w, r, &input)
@@ -31,22 +35,71 @@ func (a *application) createUserSessionHandler(w http.ResponseWriter, r *http.Re
         CreditHoursCompleted: input.CreditHoursCompleted,
         Grade:                input.Grade,
         Feedback:             input.Feedback,
+        Completed:            input.Completed,
+        OverrideReason:       input.OverrideReason,
     }
 
+    // Hours and completion normally come from attendance, anything
+    // entered by hand is an override and needs a reason
+    manual := us.CreditHoursCompleted != 0 || us.Completed
+
     // Validate input
     v := validator.New()
     data.ValidateUserSession(v, us)
+    if manual {
+        v.Check(us.OverrideReason != "", "override_reason", "must be provided when setting credit hours or completion by hand")
+    }
     if !v.IsEmpty() {
         a.failedValidationResponse(w, r, v.Errors)
         return
     }
 
+    // The session has to be one the caller can reach
+    session, err := a.sessionModel.Get(us.SessionID, a.contextGetAccessScope(r), false)
+    if err != nil {
+        if errors.Is(err, data.ErrRecordNotFound) {
+            v.AddError("session_id", "must refer to an existing session")
+            a.failedValidationResponse(w, r, v.Errors)
+        } else {
+            a.serverErrorResponse(w, r, err)
+        }
+        return
+    }
+
+    // The trainee has to have met the course's prerequisites
+    eligibility, err := a.coursePrerequisiteModel.CheckEligibility(session.CourseID, us.TraineeID)
+    if err != nil {
+        a.serverErrorResponse(w, r, err)
+        return
+    }
+    if !eligibility.Eligible {
+        a.ineligibleResponse(w, r, eligibility)
+        return
+    }
+
+    if manual {
+        a.markOverridden(r, us)
+    } else {
+        us.OverrideReason = ""
+    }
+
     // Insert record into DB
     err = a.userSessionModel.AddUserSession(us)
     if err != nil {
-        a.serverErrorResponse(w, r, err)
+        switch {
+        case errors.Is(err, data.ErrRecordNotFound):
+            v.AddError("session_id", "must refer to an existing session")
+            a.failedValidationResponse(w, r, v.Errors)
+        case errors.Is(err, data.ErrAlreadyEnrolled):
+            a.traineeAlreadyEnrolledResponse(w, r)
+        case errors.Is(err, data.ErrSessionFull):
+            a.sessionFullResponse(w, r)
+        default:
+            a.serverErrorResponse(w, r, err)
+        }
         return
     }
+    a.recordAudit(r, data.AuditActionCreate, "user_session", us.ID, nil, us)
 
     headers := make(http.Header)
     headers.Set("Location", fmt.Sprintf("/v1/usersessions/%d", us.ID))
@@ -69,7 +122,7 @@ func (a *application) getUserSessionHandler(w http.ResponseWriter, r *http.Reque
         return
     }
 
-    us, err := a.userSessionModel.GetUserSession(id)
+    us, err := a.userSessionModel.GetUserSession(id, a.contextGetAccessScope(r))
     if err != nil {
         if errors.Is(err, data.ErrRecordNotFound) {
             a.notFoundResponse(w, r)
@@ -97,7 +150,7 @@ func (a *application) updateUserSessionHandler(w http.ResponseWriter, r *http.Re
         return
     }
 
-    us, err := a.userSessionModel.GetUserSession(id)
+    us, err := a.userSessionModel.GetUserSession(id, a.contextGetAccessScope(r))
     if err != nil {
         if errors.Is(err, data.ErrRecordNotFound) {
             a.notFoundResponse(w, r)
@@ -106,11 +159,14 @@ func (a *application) updateUserSessionHandler(w http.ResponseWriter, r *http.Re
         }
         return
     }
+    before := *us
 
     var input struct {
         CreditHoursCompleted *int64  `json:"credithours_completed"`
         Grade                *string `json:"grade"`
         Feedback             *string `json:"feedback"`
+        Completed            *bool   `json:"completed"`
+        OverrideReason       *string `json:"override_reason"`
     }
 
     err = a.readJSON(w, r, &input)
@@ -129,19 +185,104 @@ func (a *application) updateUserSessionHandler(w http.ResponseWriter, r *http.Re
     if input.Feedback != nil {
         us.Feedback = *input.Feedback
     }
+    if input.Completed != nil {
+        us.Completed = *input.Completed
+    }
+
+    // Changing hours or completion by hand overrides the attendance
+    // figures and has to say why
+    manual := input.CreditHoursCompleted != nil || input.Completed != nil
+    if manual {
+        us.OverrideReason = ""
+        if input.OverrideReason != nil {
+            us.OverrideReason = *input.OverrideReason
+        }
+    }
 
     v := validator.New()
     data.ValidateUserSession(v, us)
+    if manual {
+        v.Check(us.OverrideReason != "", "override_reason", "must be provided when setting credit hours or completion by hand")
+    }
     if !v.IsEmpty() {
         a.failedValidationResponse(w, r, v.Errors)
         return
     }
 
+    if manual {
+        a.markOverridden(r, us)
+    }
+
     err = a.userSessionModel.UpdateUserSession(us)
+    if err != nil {
+        switch {
+        case errors.Is(err, data.ErrRecordNotFound):
+            a.notFoundResponse(w, r)
+        case errors.Is(err, data.ErrEditConflict):
+            a.editConflictResponse(w, r)
+        default:
+            a.serverErrorResponse(w, r, err)
+        }
+        return
+    }
+    a.recordAudit(r, data.AuditActionUpdate, "user_session", us.ID, before, us)
+
+    data := envelope{
+        "user_session": us,
+    }
+
+    err = a.writeJSON(w, http.StatusOK, data, nil)
+    if err != nil {
+        a.serverErrorResponse(w, r, err)
+    }
+}
+
+// ---------------- OVERRIDES ----------------
+
+// Record who overrode the attendance figures and when
+func (a *application) markOverridden(r *http.Request, us *data.UserSession) {
+    now := time.Now()
+    us.OverriddenBy = a.contextGetUser(r).ID
+    us.OverriddenAt = &now
+}
+
+// Removes a manual override so credit hours and completion are worked out
+// from attendance again
+func (a *application) clearUserSessionOverrideHandler(w http.ResponseWriter, r *http.Request) {
+    id, err := a.readIDParam(r)
+    if err != nil {
+        a.notFoundResponse(w, r)
+        return
+    }
+
+    scope := a.contextGetAccessScope(r)
+
+    before, err := a.userSessionModel.GetUserSession(id, scope)
+    if err != nil {
+        if errors.Is(err, data.ErrRecordNotFound) {
+            a.notFoundResponse(w, r)
+        } else {
+            a.serverErrorResponse(w, r, err)
+        }
+        return
+    }
+
+    err = a.userSessionModel.ClearOverride(id)
+    if err != nil {
+        if errors.Is(err, data.ErrRecordNotFound) {
+            a.notFoundResponse(w, r)
+        } else {
+            a.serverErrorResponse(w, r, err)
+        }
+        return
+    }
+
+    us, err := a.userSessionModel.GetUserSession(id, scope)
     if err != nil {
         a.serverErrorResponse(w, r, err)
         return
     }
+    a.recordAudit(r, data.AuditActionUpdate, "user_session", us.ID, before, us)
 
     data := envelope{
         "user_session": us,
@@ -161,7 +302,10 @@ func (a *application) deleteUserSessionHandler(w http.ResponseWriter, r *http.Re
         return
     }
 
-    err = a.userSessionModel.DeleteUserSession(id)
+    scope := a.contextGetAccessScope(r)
+
+    // Keep a copy for the audit log
+    us, err := a.userSessionModel.GetUserSession(id, scope)
     if err != nil {
         if errors.Is(err, data.ErrRecordNotFound) {
             a.notFoundResponse(w, r)
@@ -171,6 +315,17 @@ func (a *application) deleteUserSessionHandler(w http.ResponseWriter, r *http.Re
         return
     }
 
+    err = a.userSessionModel.DeleteUserSession(id, scope)
+    if err != nil {
+        if errors.Is(err, data.ErrRecordNotFound) {
+            a.notFoundResponse(w, r)
+        } else {
+            a.serverErrorResponse(w, r, err)
+        }
+        return
+    }
+    a.recordAudit(r, data.AuditActionDelete, "user_session", us.ID, us, nil)
+
     data := envelope{
         "message": fmt.Sprintf("user session %d successfully deleted", id),
     }
@@ -183,7 +338,43 @@ func (a *application) deleteUserSessionHandler(w http.ResponseWriter, r *http.Re
 
 // ---------------- LIST ----------------
 func (a *application) listUserSessionHandler(w http.ResponseWriter, r *http.Request) {
-    sessions, err := a.userSessionModel.GetAllUserSessions()
+    v := validator.New()
+    format := a.readExportFormat(r, v)
+    if !v.IsEmpty() {
+        a.failedValidationResponse(w, r, v.Errors)
+        return
+    }
+
+    scope := a.contextGetAccessScope(r)
+
+    // User sessions are not paginated so the export is a single page
+    if format != "" {
+        header := []string{"id", "trainee_id", "session_id", "credithours_completed", "grade", "feedback", "completed", "override_reason", "created_at"}
+        a.writeExport(w, r, format, "user-sessions", header, data.Filters{}, func(filters data.Filters) ([][]string, data.Metadata, error) {
+            sessions, err := a.userSessionModel.GetAllUserSessions(scope)
+            if err != nil {
+                return nil, data.Metadata{}, err
+            }
+            records := make([][]string, 0, len(sessions))
+            for _, us := range sessions {
+                records = append(records, []string{
+                    strconv.FormatInt(us.ID, 10),
+                    strconv.FormatInt(us.TraineeID, 10),
+                    strconv.FormatInt(us.SessionID, 10),
+                    strconv.FormatInt(us.CreditHoursCompleted, 10),
+                    us.Grade,
+                    us.Feedback,
+                    strconv.FormatBool(us.Completed),
+                    us.OverrideReason,
+                    us.CreatedAt.Format(time.RFC3339),
+                })
+            }
+            return records, data.Metadata{}, nil
+        })
+        return
+    }
+
+    sessions, err := a.userSessionModel.GetAllUserSessions(scope)
     if err != nil {
         a.serverErrorResponse(w, r, err)
         return
@@ -62,4 +62,28 @@ func TestDeleteUserSessionHandler_InvalidID(t *testing.T) {
     if rr.Code != http.StatusNotFound {
         t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
     }
-}
\ No newline at end of file
+}
+
+func TestCreateUserSessionHandler_OverrideWithoutReason(t *testing.T) {
+    payload := `{"trainee_id":1,"session_id":1,"credithours_completed":8,"grade":"A","feedback":"Good"}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/user_session", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    testApp.createUserSessionHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestClearUserSessionOverrideHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodDelete, "/v1/user_session//override", nil)
+    rr := httptest.NewRecorder()
+
+    testApp.clearUserSessionOverrideHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
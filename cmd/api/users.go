@@ -4,6 +4,7 @@ package main
 import (
 	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
@@ -61,49 +62,28 @@ func (app *application) registerUserHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	err = app.userModel.Insert(user) // we will add userModel to main() later
+	// New users can read sessions and get an activation token which
+	// expires in 3 days. The welcome email goes out through the outbox.
+	err = app.userModel.Register(user, 3*24*time.Hour, "session:read")
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrDuplicateEmail):
 			v.AddError("email", "a user with this email address already exists")
 			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrDuplicateUsername):
+			v.AddError("username", "a user with this username already exists")
+			app.failedValidationResponse(w, r, v.Errors)
 		default:
 			app.serverErrorResponse(w, r, err)
 		}
 		return
 	}
-
-	// Add the read permission for new users
-	err = app.permissionModel.AddForUser(user.ID, "session:read")
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
-	}
-
-	// Generate a new activation token which expires in 3 days
-	token, err := app.tokenModel.New(user.ID, 3*24*time.Hour, data.ScopeActivation)
-	if err != nil {
-		app.serverErrorResponse(w, r, err)
-		return
-	}
+	app.recordAudit(r, data.AuditActionCreate, "users", user.ID, nil, user)
 
 	data := envelope{
 		"user": user,
 	}
 
-	// Send the email as a  go routine
-	app.background(func() {
-		data := map[string]any{
-			"activationToken": token.Plaintext,
-			"userID":          user.ID,
-		}
-
-		err = app.mailer.Send(user.Email, "user_welcome.tmpl", data)
-		if err != nil {
-			app.logger.Error(err.Error())
-		}
-	})
-
 	// Status code 201 resource created
 	err = app.writeJSON(w, http.StatusCreated, data, nil)
 	if err != nil {
@@ -154,6 +134,7 @@ func (a *application) activateUserHandler(w http.ResponseWriter, r *http.Request
 		}
 		return
 	}
+	a.recordAudit(r, data.AuditActionUpdate, "users", user.ID, map[string]bool{"activated": false}, map[string]bool{"activated": true})
 
 	// Re-fetch the full user from the database so all fields are populated
 	user, err = a.userModel.GetByEmail(user.Email)
@@ -161,6 +142,7 @@ func (a *application) activateUserHandler(w http.ResponseWriter, r *http.Request
 		a.serverErrorResponse(w, r, err)
 		return
 	}
+	a.emitWebhook(r, data.WebhookEventUserActivated, user)
 
 	// User has been activated so let's delete the activation token to
 	// prevent reuse.
@@ -215,6 +197,7 @@ func (a *application) listUsersHandler(w http.ResponseWriter, r *http.Request) {
 	queryParametersData.Filters.PageSize = a.getSingleIntegerParameter(queryParameters, "page_size", 20, v)
 	queryParametersData.Filters.Sort = a.getSingleQueryParameter(queryParameters, "sort", "id")
 	queryParametersData.Filters.SortSafeList = []string{"id", "regulation_number", "username", "fname", "lname", "email", "-formation", "rank", "postings", "-id", "-regulation_number", "-username", "-fname", "-lname", "-email", "-formation", "-rank", "-postings"}
+	format := a.readExportFormat(r, v)
 
 	// Check if the filters are valid
 	data.ValidateFilters(v, queryParametersData.Filters)
@@ -223,8 +206,43 @@ func (a *application) listUsersHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	includeDeleted, ok := a.readIncludeDeleted(w, r)
+	if !ok {
+		return
+	}
+
+	scope := a.contextGetAccessScope(r)
+
+	// Send every matching user as a file if one was asked for
+	if format != "" {
+		header := []string{"id", "regulation_number", "username", "fname", "lname", "email", "gender", "formation", "rank", "postings"}
+		a.writeExport(w, r, format, "users", header, queryParametersData.Filters, func(filters data.Filters) ([][]string, data.Metadata, error) {
+			users, metadata, err := a.userModel.GetAll(queryParametersData.ID, queryParametersData.RegulationNumber, queryParametersData.Username, queryParametersData.FName, queryParametersData.LName, queryParametersData.Email, queryParametersData.Gender, queryParametersData.Formation, queryParametersData.Rank, queryParametersData.Postings, includeDeleted, filters, scope)
+			if err != nil {
+				return nil, data.Metadata{}, err
+			}
+			records := make([][]string, 0, len(users))
+			for _, user := range users {
+				records = append(records, []string{
+					strconv.FormatInt(user.ID, 10),
+					user.RegulationNumber,
+					user.Username,
+					user.FName,
+					user.LName,
+					user.Email,
+					user.Gender,
+					strconv.Itoa(user.Formation),
+					strconv.Itoa(user.Rank),
+					strconv.Itoa(user.Postings),
+				})
+			}
+			return records, metadata, nil
+		})
+		return
+	}
+
 	// Get the list of users
-	users, metadata, err := a.userModel.GetAll(queryParametersData.ID, queryParametersData.RegulationNumber, queryParametersData.Username, queryParametersData.FName, queryParametersData.LName, queryParametersData.Email, queryParametersData.Gender, queryParametersData.Formation, queryParametersData.Rank, queryParametersData.Postings, queryParametersData.Filters)
+	users, metadata, err := a.userModel.GetAll(queryParametersData.ID, queryParametersData.RegulationNumber, queryParametersData.Username, queryParametersData.FName, queryParametersData.LName, queryParametersData.Email, queryParametersData.Gender, queryParametersData.Formation, queryParametersData.Rank, queryParametersData.Postings, includeDeleted, queryParametersData.Filters, scope)
 	if err != nil {
 		a.serverErrorResponse(w, r, err)
 		return
@@ -243,6 +261,29 @@ func (a *application) listUsersHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// Look up the user named in the URL, sending a 404 if they don't exist or
+// are outside the caller's scope
+func (app *application) readUserParam(w http.ResponseWriter, r *http.Request) (*data.User, bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	user, err := app.userModel.GetByID(id, app.contextGetAccessScope(r), false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return user, true
+}
+
 // Update an existing user
 func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request) {
 	// Get the id from the URL
@@ -252,7 +293,7 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	user, err := app.userModel.GetByID(id)
+	user, err := app.userModel.GetByID(id, app.contextGetAccessScope(r), false)
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -262,6 +303,7 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 		}
 		return
 	}
+	before := *user
 
 	// Read the incoming JSON
 	var incomingData struct {
@@ -319,12 +361,31 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Officers can only be moved to a formation the caller manages
+	if !app.contextGetAccessScope(r).Includes(int64(user.Formation)) {
+		v.AddError("formation", "must be a formation within your scope")
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
 	// Update the user in the database
 	err = app.userModel.Update(user)
 	if err != nil {
-		app.serverErrorResponse(w, r, err)
+		switch {
+		case errors.Is(err, data.ErrDuplicateEmail):
+			v.AddError("email", "a user with this email address already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrDuplicateUsername):
+			v.AddError("username", "a user with this username already exists")
+			app.failedValidationResponse(w, r, v.Errors)
+		case errors.Is(err, data.ErrEditConflict):
+			app.editConflictResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
 		return
 	}
+	app.recordAudit(r, data.AuditActionUpdate, "users", user.ID, before, user)
 
 	// Send a JSON response with the updated user
 	data := envelope{
@@ -340,14 +401,13 @@ func (app *application) updateUserHandler(w http.ResponseWriter, r *http.Request
 
 // Delete user
 func (app *application) deleteUserHandler(w http.ResponseWriter, r *http.Request) {
-	// Get the id from the URL
-	id, err := app.readIDParam(r)
-	if err != nil {
-		app.notFoundResponse(w, r)
+	// Keep a copy of the user for the audit log
+	user, ok := app.readUserParam(w, r)
+	if !ok {
 		return
 	}
 
-	err = app.userModel.Delete(id)
+	err := app.userModel.Delete(user.ID, app.contextGetAccessScope(r))
 	if err != nil {
 		switch {
 		case errors.Is(err, data.ErrRecordNotFound):
@@ -358,6 +418,8 @@ func (app *application) deleteUserHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	app.recordAudit(r, data.AuditActionDelete, "users", user.ID, user, nil)
+
 	// display the quote
 	data := envelope{"message": "user successfully deleted"}
 
@@ -368,6 +430,60 @@ func (app *application) deleteUserHandler(w http.ResponseWriter, r *http.Request
 	}
 }
 
+// Bring back a deleted user. They can sign in again with their old password.
+func (app *application) restoreUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	scope := app.contextGetAccessScope(r)
+
+	before, err := app.userModel.GetByID(id, scope, true)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	if before.DeletedAt == nil {
+		app.notDeletedResponse(w, r, "user")
+		return
+	}
+
+	err = app.userModel.Restore(id, scope)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	user, err := app.userModel.GetByID(id, scope, false)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditActionUpdate, "users", user.ID, before, user)
+
+	data := envelope{
+		"user": user,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
 func (app *application) updatePasswordHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse user ID from the URL
 	id, err := app.readIDParam(r)
@@ -392,12 +508,25 @@ func (app *application) updatePasswordHandler(w http.ResponseWriter, r *http.Req
 		return
 	}
 
+	_, err = app.userModel.GetByID(id, app.contextGetAccessScope(r), false)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
 	// Call model method
 	err = app.userModel.UpdatePassword(id, input.NewPassword)
 	if err != nil {
 		app.serverErrorResponse(w, r, err)
 		return
 	}
+	// The hash is never written to the log, only that the password changed
+	app.recordAudit(r, data.AuditActionUpdate, "users", id, nil, nil)
 
 	// Respond success
 	env := envelope{"message": "password updated successfully"}
@@ -406,3 +535,53 @@ func (app *application) updatePasswordHandler(w http.ResponseWriter, r *http.Req
 		app.serverErrorResponse(w, r, err)
 	}
 }
+
+// Set a new password using a token from a password reset email. Every
+// authentication token the user holds is revoked so other devices are
+// signed out.
+func (app *application) resetUserPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		Password       string `json:"password"`
+		TokenPlaintext string `json:"token"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	v := validator.New()
+	data.ValidatePasswordPlaintext(v, incomingData.Password)
+	data.ValidateTokenPlaintext(v, incomingData.TokenPlaintext)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	user, err := app.userModel.GetForToken(data.ScopePasswordReset, incomingData.TokenPlaintext)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			v.AddError("token", "invalid or expired password reset token")
+			app.failedValidationResponse(w, r, v.Errors)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	// The reset token is single use and existing sign-ins end with it
+	err = app.userModel.ResetPassword(user.ID, incomingData.Password)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditActionUpdate, "users", user.ID, nil, nil)
+
+	env := envelope{"message": "your password was successfully reset"}
+	err = app.writeJSON(w, http.StatusOK, env, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
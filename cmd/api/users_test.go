@@ -105,6 +105,18 @@ func TestDeleteUserHandler_InvalidID(t *testing.T) {
     }
 }
 
+func TestRestoreUserHandler_InvalidID(t *testing.T) {
+    app := newTestApp()
+    req := httptest.NewRequest(http.MethodPost, "/v1/users/restore/", nil)
+    rr := httptest.NewRecorder()
+
+    app.restoreUserHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
+
 func TestUpdatePasswordHandler_MissingNewPassword(t *testing.T) {
     app := newTestApp()
     // Missing new_password should trigger validation 422, but calling without an id param returns 404 first.
@@ -117,4 +129,18 @@ func TestUpdatePasswordHandler_MissingNewPassword(t *testing.T) {
     if rr.Code != http.StatusNotFound {
         t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
     }
-}
\ No newline at end of file
+}
+func TestResetUserPasswordHandler_InvalidData(t *testing.T) {
+    app := newTestApp()
+    // short password and a token of the wrong length
+    payload := `{"password": "short", "token": "abc"}`
+    req := httptest.NewRequest(http.MethodPut, "/v1/users/password", bytes.NewBufferString(payload))
+    req.Header.Set("Content-Type", "application/json")
+    rr := httptest.NewRecorder()
+
+    app.resetUserPasswordHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
@@ -0,0 +1,126 @@
+// Filename: cmd/api/webhook_delivery.go
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/webhook"
+)
+
+// How long claimed deliveries are held for while a batch is sent
+const webhookLease = 10 * time.Minute
+
+// Queue an event for every subscription that wants it. The change has
+// already been made so a failure here can only be reported, not undone.
+func (app *application) emitWebhook(r *http.Request, event string, payload any) {
+	err := app.webhookModel.Enqueue(event, payload)
+	if err != nil {
+		app.logError(r, err)
+	}
+}
+
+// Start the webhook worker. It sends what is due straight away and then
+// every webhooks.interval until ctx is cancelled, checking for lapsed
+// qualifications every webhooks.complianceInterval. It is tracked by app.wg
+// so shutdown waits for a batch in progress to finish.
+func (app *application) startWebhooks(ctx context.Context) {
+	app.wg.Add(1)
+
+	go func() {
+		defer app.wg.Done()
+
+		ticker := time.NewTicker(app.config.webhooks.interval)
+		defer ticker.Stop()
+
+		var checkedCompliance time.Time
+		for {
+			if time.Since(checkedCompliance) >= app.config.webhooks.complianceInterval {
+				app.runJob("compliance check", app.queueComplianceFailures)
+				checkedCompliance = time.Now()
+			}
+			app.runJob("webhook batch", func() { app.deliverWebhooks(ctx) })
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Queue compliance.failed for qualifications that have lapsed since the last
+// check. Lapses that have already been queued are skipped by the model.
+func (app *application) queueComplianceFailures() {
+	queued, err := app.webhookModel.EnqueueComplianceFailures()
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	if queued > 0 {
+		app.logger.Info("queued compliance failures", "count", queued)
+	}
+}
+
+// Send a batch of due deliveries. Failures are tried again later with the
+// same backoff as emails until they run out of attempts.
+func (app *application) deliverWebhooks(ctx context.Context) {
+	deliveries, err := app.webhookModel.ClaimDue(app.config.webhooks.batchSize, webhookLease)
+	if err != nil {
+		app.logger.Error(err.Error())
+		return
+	}
+
+	for _, delivery := range deliveries {
+		// Anything left when shutting down is picked up again once its
+		// lease runs out
+		if ctx.Err() != nil {
+			return
+		}
+
+		statusCode, err := app.sendWebhook(ctx, delivery)
+		if err == nil {
+			err = app.webhookModel.MarkDelivered(delivery, statusCode)
+			if err != nil {
+				app.logger.Error(err.Error(), "delivery_id", delivery.ID)
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		attempts := delivery.Attempts + 1
+		deadLetter := attempts >= app.config.webhooks.maxAttempts
+		if deadLetter {
+			app.logger.Error("webhook failed", "delivery_id", delivery.ID, "attempts", attempts, "error", err.Error())
+		} else {
+			app.logger.Warn("webhook not delivered, will retry", "delivery_id", delivery.ID, "attempts", attempts, "error", err.Error())
+		}
+
+		err = app.webhookModel.RecordFailure(delivery, statusCode, err.Error(), time.Now().Add(outboxBackoff(attempts)), deadLetter)
+		if err != nil {
+			app.logger.Error(err.Error(), "delivery_id", delivery.ID)
+		}
+	}
+}
+
+// Post one delivery to its subscription
+func (app *application) sendWebhook(ctx context.Context, delivery *data.WebhookDelivery) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, app.config.webhooks.timeout)
+	defer cancel()
+
+	event := &webhook.Event{
+		ID:        delivery.ID,
+		Event:     delivery.Event,
+		DedupKey:  delivery.DedupKey,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	}
+
+	return app.webhookClient.Send(ctx, delivery.URL, delivery.Secret, event)
+}
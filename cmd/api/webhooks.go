@@ -0,0 +1,332 @@
+// Filename: cmd/api/webhooks.go
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/data"
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Subscribes a system to events. The response is the only time the signing
+// secret is shown.
+func (app *application) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var incomingData struct {
+		URL         string   `json:"url"`
+		Events      []string `json:"events"`
+		Description string   `json:"description"`
+		Active      *bool    `json:"active"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	subscription := &data.WebhookSubscription{
+		URL:         incomingData.URL,
+		Events:      incomingData.Events,
+		Description: incomingData.Description,
+		Active:      true,
+	}
+	if incomingData.Active != nil {
+		subscription.Active = *incomingData.Active
+	}
+
+	v := validator.New()
+	data.ValidateWebhookSubscription(v, subscription)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.webhookModel.Insert(subscription)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	// Keep the secret out of the audit log
+	audited := *subscription
+	audited.Secret = ""
+	app.recordAudit(r, data.AuditActionCreate, "webhook", subscription.ID, nil, audited)
+
+	headers := make(http.Header)
+	headers.Set("Location", fmt.Sprintf("/v1/admin/webhooks/%d", subscription.ID))
+
+	data := envelope{
+		"webhook": subscription,
+	}
+
+	err = app.writeJSON(w, http.StatusCreated, data, headers)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Look up the subscription named in the URL, sending a 404 if it doesn't exist
+func (app *application) readWebhookParam(w http.ResponseWriter, r *http.Request) (*data.WebhookSubscription, bool) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return nil, false
+	}
+
+	subscription, err := app.webhookModel.Get(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return nil, false
+	}
+
+	return subscription, true
+}
+
+// Displays a subscription
+func (app *application) displayWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := app.readWebhookParam(w, r)
+	if !ok {
+		return
+	}
+
+	data := envelope{
+		"webhook": subscription,
+	}
+
+	err := app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Edit a subscription. Setting rotate_secret replaces the signing secret and
+// shows the new one in the response.
+func (app *application) updateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := app.readWebhookParam(w, r)
+	if !ok {
+		return
+	}
+	before := *subscription
+
+	var incomingData struct {
+		URL          *string  `json:"url"`
+		Events       []string `json:"events"`
+		Description  *string  `json:"description"`
+		Active       *bool    `json:"active"`
+		RotateSecret bool     `json:"rotate_secret"`
+	}
+
+	err := app.readJSON(w, r, &incomingData)
+	if err != nil {
+		app.badRequestResponse(w, r, err)
+		return
+	}
+
+	if incomingData.URL != nil {
+		subscription.URL = *incomingData.URL
+	}
+	if incomingData.Events != nil {
+		subscription.Events = incomingData.Events
+	}
+	if incomingData.Description != nil {
+		subscription.Description = *incomingData.Description
+	}
+	if incomingData.Active != nil {
+		subscription.Active = *incomingData.Active
+	}
+
+	v := validator.New()
+	data.ValidateWebhookSubscription(v, subscription)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	err = app.webhookModel.Update(subscription, incomingData.RotateSecret)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	audited := *subscription
+	audited.Secret = ""
+	app.recordAudit(r, data.AuditActionUpdate, "webhook", subscription.ID, before, audited)
+
+	data := envelope{
+		"webhook": subscription,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Delete a subscription and its delivery log
+func (app *application) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	// Keep a copy for the audit log
+	subscription, ok := app.readWebhookParam(w, r)
+	if !ok {
+		return
+	}
+
+	err := app.webhookModel.Delete(subscription.ID)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+	app.recordAudit(r, data.AuditActionDelete, "webhook", subscription.ID, subscription, nil)
+
+	data := envelope{"message": "webhook successfully deleted"}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// List all subscriptions
+func (app *application) listWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+
+	v := validator.New()
+	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 10, v)
+	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "id")
+	queryParametersData.Filters.SortSafeList = []string{"id", "created_at", "-id", "-created_at"}
+
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	subscriptions, metadata, err := app.webhookModel.GetAll(queryParametersData.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"webhooks":  subscriptions,
+		"@metadata": metadata,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// The delivery log for a subscription, newest first
+func (app *application) listWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	var queryParametersData struct {
+		Status string
+		data.Filters
+	}
+
+	queryParameters := r.URL.Query()
+	v := validator.New()
+
+	queryParametersData.Status = app.getSingleQueryParameter(queryParameters, "status", "")
+
+	queryParametersData.Filters.Page = app.getSingleIntegerParameter(queryParameters, "page", 1, v)
+	queryParametersData.Filters.PageSize = app.getSingleIntegerParameter(queryParameters, "page_size", 20, v)
+	queryParametersData.Filters.Sort = app.getSingleQueryParameter(queryParameters, "sort", "-created_at")
+	queryParametersData.Filters.SortSafeList = []string{"id", "created_at", "-id", "-created_at"}
+
+	if queryParametersData.Status != "" {
+		v.Check(validator.PermittedValue(queryParametersData.Status, data.WebhookDeliveryStatuses...), "status", "must be one of pending, delivered or failed")
+	}
+
+	data.ValidateFilters(v, queryParametersData.Filters)
+	if !v.IsEmpty() {
+		app.failedValidationResponse(w, r, v.Errors)
+		return
+	}
+
+	subscription, ok := app.readWebhookParam(w, r)
+	if !ok {
+		return
+	}
+
+	deliveries, metadata, err := app.webhookModel.GetDeliveries(subscription.ID, queryParametersData.Status, queryParametersData.Filters)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+
+	data := envelope{
+		"deliveries": deliveries,
+		"@metadata":  metadata,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
+
+// Sends a ping to a subscription straight away and reports how it went. A
+// failed ping isn't retried.
+func (app *application) pingWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := app.readIDParam(r)
+	if err != nil {
+		app.notFoundResponse(w, r)
+		return
+	}
+
+	delivery, err := app.webhookModel.CreatePing(id)
+	if err != nil {
+		switch {
+		case errors.Is(err, data.ErrRecordNotFound):
+			app.notFoundResponse(w, r)
+		default:
+			app.serverErrorResponse(w, r, err)
+		}
+		return
+	}
+
+	statusCode, sendErr := app.sendWebhook(r.Context(), delivery)
+	if sendErr == nil {
+		err = app.webhookModel.MarkDelivered(delivery, statusCode)
+	} else {
+		err = app.webhookModel.RecordFailure(delivery, statusCode, sendErr.Error(), time.Now(), true)
+	}
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+		return
+	}
+	app.recordAudit(r, data.AuditActionCreate, "webhook_delivery", delivery.ID, nil, delivery)
+
+	data := envelope{
+		"delivery": delivery,
+	}
+
+	err = app.writeJSON(w, http.StatusOK, data, nil)
+	if err != nil {
+		app.serverErrorResponse(w, r, err)
+	}
+}
@@ -0,0 +1,115 @@
+// Filename: cmd/api/webhooks_test.go
+package main
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/http/httptest"
+    "strconv"
+    "testing"
+    "time"
+
+    "github.com/julienschmidt/httprouter"
+    "github.com/kelseyaban/National-Inservice-Training-Database/internal/webhook"
+)
+
+func TestWebhookClient_SignsDelivery(t *testing.T) {
+    const secret = "s3cret"
+
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        body, _ := io.ReadAll(r.Body)
+        timestamp, err := strconv.ParseInt(r.Header.Get(webhook.HeaderTimestamp), 10, 64)
+        if err != nil {
+            t.Errorf("bad timestamp header: %v", err)
+        }
+        if got, want := r.Header.Get(webhook.HeaderSignature), webhook.Sign(secret, timestamp, body); got != want {
+            t.Errorf("expected signature %s; got %s", want, got)
+        }
+        if r.Header.Get(webhook.HeaderEvent) != "session.created" || r.Header.Get(webhook.HeaderID) != "9" {
+            t.Errorf("unexpected event headers %v", r.Header)
+        }
+
+        var event webhook.Event
+        if err := json.Unmarshal(body, &event); err != nil || string(event.Data) != `{"id":3}` {
+            t.Errorf("unexpected body %s", body)
+        }
+        w.WriteHeader(http.StatusNoContent)
+    }))
+    defer srv.Close()
+
+    event := &webhook.Event{ID: 9, Event: "session.created", CreatedAt: time.Now(), Data: json.RawMessage(`{"id":3}`)}
+    status, err := webhook.New(time.Second).Send(context.Background(), srv.URL, secret, event)
+    if err != nil {
+        t.Fatal(err)
+    }
+    if status != http.StatusNoContent {
+        t.Fatalf("expected status %d; got %d", http.StatusNoContent, status)
+    }
+}
+
+func TestWebhookClient_ErrorStatus(t *testing.T) {
+    srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        w.WriteHeader(http.StatusInternalServerError)
+    }))
+    defer srv.Close()
+
+    event := &webhook.Event{ID: 1, Event: "ping", Data: json.RawMessage(`{}`)}
+    status, err := webhook.New(time.Second).Send(context.Background(), srv.URL, "s3cret", event)
+    if err == nil {
+        t.Fatal("expected an error for a 500 response")
+    }
+    if status != http.StatusInternalServerError {
+        t.Fatalf("expected status %d; got %d", http.StatusInternalServerError, status)
+    }
+}
+
+func TestCreateWebhookHandler_InvalidURL(t *testing.T) {
+    body := `{"url": "ftp://hr.example.com/hooks", "events": ["session.created"]}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks", bytes.NewBufferString(body))
+    rr := httptest.NewRecorder()
+
+    testApp.createWebhookHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestCreateWebhookHandler_UnknownEvent(t *testing.T) {
+    body := `{"url": "https://hr.example.com/hooks", "events": ["officer.promoted"]}`
+    req := httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks", bytes.NewBufferString(body))
+    rr := httptest.NewRecorder()
+
+    testApp.createWebhookHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestListWebhookDeliveriesHandler_InvalidStatus(t *testing.T) {
+    req := httptest.NewRequest(http.MethodGet, "/v1/admin/webhooks/1/deliveries?status=lost", nil)
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "1"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.listWebhookDeliveriesHandler(rr, req)
+
+    if rr.Code != http.StatusUnprocessableEntity {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusUnprocessableEntity, rr.Code, rr.Body.String())
+    }
+}
+
+func TestPingWebhookHandler_InvalidID(t *testing.T) {
+    req := httptest.NewRequest(http.MethodPost, "/v1/admin/webhooks/abc/ping", nil)
+    req = req.WithContext(context.WithValue(req.Context(), httprouter.ParamsKey, httprouter.Params{{Key: "id", Value: "abc"}}))
+    rr := httptest.NewRecorder()
+
+    testApp.pingWebhookHandler(rr, req)
+
+    if rr.Code != http.StatusNotFound {
+        t.Fatalf("expected status %d; got %d; body=%s", http.StatusNotFound, rr.Code, rr.Body.String())
+    }
+}
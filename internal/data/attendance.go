@@ -4,9 +4,11 @@ package data
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+	"github.com/lib/pq"
 )
 
 type Attendance struct {
@@ -45,12 +47,39 @@ func (a AttendanceModel) Insert(attendance *Attendance) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := a.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// execute query against the database
-	return a.DB.QueryRowContext(ctx, query, args...).Scan(&attendance.ID, &attendance.CreatedAt)
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&attendance.ID, &attendance.CreatedAt)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateAttendance
+		}
+		return err
+	}
+
+	// keep the trainee's credit hours in step with their attendance
+	err = recalculateCredit(ctx, tx, []int64{attendance.UserSessionID})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
-// Get a specific attendance record of user from the database
-func (a AttendanceModel) GetIdividualAttendance(id int64) (*Attendance, error) {
+// Attendance belongs to the formation running the session it was taken for
+const attendanceScopeFilter = `($%d OR user_session_id IN (
+			SELECT us.id FROM user_session us
+			INNER JOIN session s ON s.id = us.session_id
+			WHERE s.formation_id = ANY($%d)))`
+
+// Get a specific attendance record of user from the database. Records
+// outside the scope are reported as not found.
+func (a AttendanceModel) GetIdividualAttendance(id int64, scope *AccessScope) (*Attendance, error) {
 	// Check if the id is valid
 	if id < 1 {
 		return nil, ErrRecordNotFound
@@ -59,7 +88,8 @@ func (a AttendanceModel) GetIdividualAttendance(id int64) (*Attendance, error) {
 	query := `
 		SELECT id, user_session_id, attendance, date, created_at
 		FROM attendance
-		WHERE id = $1`
+		WHERE id = $1
+		AND ` + fmt.Sprintf(attendanceScopeFilter, 2, 3)
 
 	var attendance Attendance
 
@@ -68,7 +98,7 @@ func (a AttendanceModel) GetIdividualAttendance(id int64) (*Attendance, error) {
 	defer cancel()
 
 	// execute query against the database
-	err := a.DB.QueryRowContext(ctx, query, id).Scan(
+	err := a.DB.QueryRowContext(ctx, query, id, scope.National, pq.Array(scope.FormationIDs)).Scan(
 		&attendance.ID,
 		&attendance.UserSessionID,
 		&attendance.AttendanceStatus,
@@ -87,19 +117,37 @@ func (a AttendanceModel) GetIdividualAttendance(id int64) (*Attendance, error) {
 	return &attendance, nil
 }
 
-// Get all attendance records from the database
-func (a AttendanceModel) GetAll() ([]*Attendance, error) {
+// Get all attendance records within the scope from the database
+func (a AttendanceModel) GetAll(scope *AccessScope) ([]*Attendance, error) {
 	query := `
 		SELECT id, user_session_id, attendance, date, created_at
 		FROM attendance
+		WHERE ` + fmt.Sprintf(attendanceScopeFilter, 1, 2) + `
 		ORDER BY created_at DESC`
 
+	return a.queryAttendance(query, scope.National, pq.Array(scope.FormationIDs))
+}
+
+// Get every attendance record across all of a trainee's sessions
+func (a AttendanceModel) GetAllForTrainee(traineeID int64) ([]*Attendance, error) {
+	query := `
+		SELECT a.id, a.user_session_id, a.attendance, a.date, a.created_at
+		FROM attendance a
+		INNER JOIN user_session us ON us.id = a.user_session_id
+		WHERE us.trainee_id = $1
+		ORDER BY a.date DESC, a.id DESC`
+
+	return a.queryAttendance(query, traineeID)
+}
+
+// Run a query selecting the attendance columns in the order above
+func (a AttendanceModel) queryAttendance(query string, args ...any) ([]*Attendance, error) {
 	// Context with a 3-second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
 	// execute query against the database
-	rows, err := a.DB.QueryContext(ctx, query)
+	rows, err := a.DB.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -146,12 +194,150 @@ func (a AttendanceModel) Update(attendance *Attendance) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	tx, err := a.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
 	// execute query against the database
-	return a.DB.QueryRowContext(ctx, query, args...).Scan(
+	err = tx.QueryRowContext(ctx, query, args...).Scan(
 		&attendance.ID,
 		&attendance.UserSessionID,
 		&attendance.AttendanceStatus,
 		&attendance.Date,
 		&attendance.CreatedAt,
 	)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateAttendance
+		}
+		return err
+	}
+
+	// keep the trainee's credit hours in step with their attendance
+	err = recalculateCredit(ctx, tx, []int64{attendance.UserSessionID})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// One trainee's mark on a roll-call
+type RollCallEntry struct {
+	UserSessionID int64 `json:"user_session_id"`
+	Present       bool  `json:"present"`
+}
+
+// Performs the checks on a whole day's roll-call
+func ValidateRollCall(v *validator.Validator, date time.Time, entries []*RollCallEntry) {
+	v.Check(!date.IsZero(), "date", "must be provided")
+	v.Check(len(entries) > 0, "entries", "must contain at least one entry")
+	v.Check(len(entries) <= 500, "entries", "must not contain more than 500 entries")
+
+	seen := make(map[int64]bool, len(entries))
+	for _, entry := range entries {
+		v.Check(entry.UserSessionID > 0, "entries", "every entry must have a user_session_id")
+		v.Check(!seen[entry.UserSessionID], "entries", "must not contain the same user_session_id more than once")
+		seen[entry.UserSessionID] = true
+	}
+}
+
+// Record a day's roll-call for a session in one transaction. Every entry
+// must belong to the session. Existing marks for the same day are
+// overwritten so re-submitting a roll-call is safe. previous holds what each
+// overwritten mark was before, and nil for marks that are new.
+func (a AttendanceModel) RecordRollCall(sessionID int64, date time.Time, entries []*RollCallEntry) (attendances, previous []*Attendance, err error) {
+	if sessionID < 1 {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := a.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM session WHERE id = $1 AND deleted_at IS NULL)`, sessionID).Scan(&exists)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exists {
+		return nil, nil, ErrRecordNotFound
+	}
+
+	ids := make([]int64, len(entries))
+	for i, entry := range entries {
+		ids[i] = entry.UserSessionID
+	}
+
+	// Find any user sessions in the roll-call that aren't for this session
+	query := `
+		SELECT COALESCE(array_agg(t.id ORDER BY t.id), '{}')
+		FROM UNNEST($2::bigint[]) AS t(id)
+		WHERE t.id NOT IN (SELECT us.id FROM user_session us WHERE us.session_id = $1)`
+
+	var notEnrolled []int64
+	err = tx.QueryRowContext(ctx, query, sessionID, pq.Array(ids)).Scan(pq.Array(&notEnrolled))
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(notEnrolled) > 0 {
+		return nil, nil, fmt.Errorf("%w: %v", ErrNotEnrolled, notEnrolled)
+	}
+
+	// old is read from the snapshot taken before the insert, so it holds the
+	// mark being overwritten, if there was one
+	query = `
+		WITH old AS (
+			SELECT attendance
+			FROM attendance
+			WHERE user_session_id = $1 AND date = $3
+		)
+		INSERT INTO attendance (user_session_id, attendance, date)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_session_id, date)
+		DO UPDATE SET attendance = EXCLUDED.attendance
+		RETURNING id, created_at, (SELECT attendance FROM old)`
+
+	attendances = make([]*Attendance, 0, len(entries))
+	previous = make([]*Attendance, 0, len(entries))
+	for _, entry := range entries {
+		attendance := &Attendance{
+			UserSessionID:    entry.UserSessionID,
+			AttendanceStatus: entry.Present,
+			Date:             date,
+		}
+		var was sql.NullBool
+		err = tx.QueryRowContext(ctx, query, attendance.UserSessionID, attendance.AttendanceStatus, attendance.Date).Scan(&attendance.ID, &attendance.CreatedAt, &was)
+		if err != nil {
+			return nil, nil, err
+		}
+		attendances = append(attendances, attendance)
+
+		if was.Valid {
+			before := *attendance
+			before.AttendanceStatus = was.Bool
+			previous = append(previous, &before)
+		} else {
+			previous = append(previous, nil)
+		}
+	}
+
+	err = recalculateCredit(ctx, tx, ids)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attendances, previous, nil
 }
@@ -0,0 +1,196 @@
+// Filename: internal/data/audit.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// What was done to a record
+const (
+	AuditActionCreate = "create"
+	AuditActionUpdate = "update"
+	AuditActionDelete = "delete"
+)
+
+// The value of one field before and after a change. Before is nil for
+// records that were just created and After is nil for deleted ones.
+type AuditChange struct {
+	Before any `json:"before"`
+	After  any `json:"after"`
+}
+
+// One change made to a record and who made it
+type AuditEntry struct {
+	ID         int64                  `json:"id"`
+	ActorID    int64                  `json:"actor_id"`
+	Action     string                 `json:"action"`
+	EntityType string                 `json:"entity_type"`
+	EntityID   int64                  `json:"entity_id"`
+	Changes    map[string]AuditChange `json:"changes"`
+	IPAddress  string                 `json:"ip_address"`
+	CreatedAt  time.Time              `json:"created_at"`
+}
+
+// Compare two versions of a record field by field using their JSON form and
+// keep only the fields that differ. Either side may be nil.
+func AuditDiff(before, after any) (map[string]AuditChange, error) {
+	beforeFields, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]AuditChange{}
+	for key, value := range beforeFields {
+		if !reflect.DeepEqual(value, afterFields[key]) {
+			changes[key] = AuditChange{Before: value, After: afterFields[key]}
+		}
+	}
+	for key, value := range afterFields {
+		if _, seen := beforeFields[key]; !seen {
+			changes[key] = AuditChange{After: value}
+		}
+	}
+
+	return changes, nil
+}
+
+// The JSON fields of a record. Values that aren't JSON objects are kept
+// under a single "value" field.
+func auditFields(record any) (map[string]any, error) {
+	if record == nil || (reflect.ValueOf(record).Kind() == reflect.Pointer && reflect.ValueOf(record).IsNil()) {
+		return map[string]any{}, nil
+	}
+
+	js, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var value any
+	err = json.Unmarshal(js, &value)
+	if err != nil {
+		return nil, err
+	}
+
+	if fields, ok := value.(map[string]any); ok {
+		return fields, nil
+	}
+	return map[string]any{"value": value}, nil
+}
+
+type AuditModel struct {
+	DB *sql.DB
+}
+
+// Save audit entries, all or none
+func (a AuditModel) Insert(entries ...*AuditEntry) error {
+	query := `
+		INSERT INTO audit_log (actor_id, action, entity_type, entity_id, changes, ip_address)
+		VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := a.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		if entry.Changes == nil {
+			entry.Changes = map[string]AuditChange{}
+		}
+		changes, err := json.Marshal(entry.Changes)
+		if err != nil {
+			return err
+		}
+
+		args := []any{entry.ActorID, entry.Action, entry.EntityType, entry.EntityID, changes, entry.IPAddress}
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&entry.ID, &entry.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// List audit entries, optionally only those made by one actor, for one
+// entity type or record, or made on or after from and before to. Zero
+// values leave a filter off.
+func (a AuditModel) GetAll(actorID int64, entityType string, entityID int64, from, to time.Time, filters Filters) ([]*AuditEntry, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), id, COALESCE(actor_id, 0), action, entity_type, entity_id, changes, ip_address, created_at
+		FROM audit_log
+		WHERE ($1 = 0 OR actor_id = $1)
+		AND ($2 = '' OR entity_type = $2)
+		AND ($3 = 0 OR entity_id = $3)
+		AND ($4::timestamptz IS NULL OR created_at >= $4)
+		AND ($5::timestamptz IS NULL OR created_at < $5)
+		ORDER BY %s %s, id DESC
+		LIMIT $6 OFFSET $7`, filters.sortColumn(), filters.sortDirection())
+
+	args := []any{
+		actorID,
+		entityType,
+		entityID,
+		sql.NullTime{Time: from, Valid: !from.IsZero()},
+		sql.NullTime{Time: to, Valid: !to.IsZero()},
+		filters.limit(),
+		filters.offset(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := a.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	entries := []*AuditEntry{}
+
+	for rows.Next() {
+		var entry AuditEntry
+		var changes []byte
+		err := rows.Scan(
+			&totalRecords,
+			&entry.ID,
+			&entry.ActorID,
+			&entry.Action,
+			&entry.EntityType,
+			&entry.EntityID,
+			&changes,
+			&entry.IPAddress,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		err = json.Unmarshal(changes, &entry.Changes)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		entries = append(entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+	return entries, metadata, nil
+}
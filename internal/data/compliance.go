@@ -0,0 +1,386 @@
+// Filename: internal/data/compliance.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Overall training status of an officer
+const (
+	ComplianceStatusCompliant    = "compliant"
+	ComplianceStatusNonCompliant = "non-compliant"
+)
+
+// One course required by the officer's posting and rank, with the hours
+// they have earned towards it so far
+type CourseRequirement struct {
+	CourseID      int64  `json:"course_id"`
+	Course        string `json:"course"`
+	Mandatory     bool   `json:"mandatory"`
+	HoursRequired int64  `json:"hours_required"`
+	HoursEarned   int64  `json:"hours_earned"`
+	Met           bool   `json:"met"`
+	// When the latest completion that still counts lapses, for courses
+	// with a validity period
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type Compliance struct {
+	UserID       int64                `json:"user_id"`
+	PostingID    int64                `json:"posting_id"`
+	RankID       int64                `json:"rank_id"`
+	Status       string               `json:"status"`
+	Requirements []*CourseRequirement `json:"requirements"`
+}
+
+// Work out the overall status. Only mandatory courses count towards
+// compliance; electives are listed for information.
+func (c *Compliance) calculateStatus() {
+	c.Status = ComplianceStatusCompliant
+	for _, requirement := range c.Requirements {
+		if requirement.Mandatory && !requirement.Met {
+			c.Status = ComplianceStatusNonCompliant
+			return
+		}
+	}
+}
+
+type ComplianceModel struct {
+	DB *sql.DB
+}
+
+// Get the compliance of a single officer against the course_posting
+// requirements for their current posting and rank
+func (c ComplianceModel) GetForUser(userID int64) (*Compliance, error) {
+	if userID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	compliance := &Compliance{
+		UserID:       userID,
+		Requirements: []*CourseRequirement{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	// rank_id and posting_id are nullable, an officer without them has
+	// no requirements
+	query := `
+		SELECT COALESCE(posting_id, 0), COALESCE(rank_id, 0)
+		FROM users
+		WHERE id = $1`
+
+	err := c.DB.QueryRowContext(ctx, query, userID).Scan(&compliance.PostingID, &compliance.RankID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	// Hours are summed over every session the officer attended for the
+	// course, leaving out any that have expired or been deleted
+	query = `
+		SELECT c.id, c.course, cp.mandatory, cp.credithours,
+		       COALESCE(SUM(us.credithours_completed), 0),
+		       MAX(CASE WHEN us.completed THEN ` + courseCompletionExpiry + ` END)
+		FROM course_posting cp
+		INNER JOIN course c ON c.id = cp.course_id AND c.deleted_at IS NULL
+		LEFT JOIN session s ON s.course_id = cp.course_id AND s.deleted_at IS NULL
+		LEFT JOIN user_session us ON us.session_id = s.id AND us.trainee_id = $1
+		      AND ` + courseCompletionValid + `
+		WHERE cp.posting_id = $2 AND cp.rank_id = $3
+		GROUP BY cp.id, c.id, c.course, cp.mandatory, cp.credithours
+		ORDER BY cp.mandatory DESC, c.course ASC`
+
+	rows, err := c.DB.QueryContext(ctx, query, userID, compliance.PostingID, compliance.RankID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var requirement CourseRequirement
+		err := rows.Scan(
+			&requirement.CourseID,
+			&requirement.Course,
+			&requirement.Mandatory,
+			&requirement.HoursRequired,
+			&requirement.HoursEarned,
+			&requirement.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		requirement.Met = requirement.HoursEarned >= requirement.HoursRequired
+		compliance.Requirements = append(compliance.Requirements, &requirement)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	compliance.calculateStatus()
+
+	return compliance, nil
+}
+
+// ------------------- REPORTS -------------------
+
+// Counts of officers by compliance status. Partially compliant officers
+// have met some, but not all, of their mandatory courses.
+type ComplianceSummary struct {
+	Officers           int `json:"officers"`
+	Compliant          int `json:"compliant"`
+	PartiallyCompliant int `json:"partially_compliant"`
+	NonCompliant       int `json:"non_compliant"`
+}
+
+type FormationCompliance struct {
+	FormationID int64  `json:"formation_id"`
+	Formation   string `json:"formation"`
+	RegionID    int64  `json:"region_id"`
+	ComplianceSummary
+}
+
+// A mandatory course and how many officers are still short of its hours
+type MissingCourse struct {
+	CourseID        int64  `json:"course_id"`
+	Course          string `json:"course"`
+	OfficersMissing int    `json:"officers_missing"`
+}
+
+type ComplianceReport struct {
+	Totals            ComplianceSummary      `json:"totals"`
+	Formations        []*FormationCompliance `json:"formations"`
+	TopMissingCourses []*MissingCourse       `json:"top_missing_courses"`
+}
+
+// complianceOfficersCTE works out, for every officer matching the filters
+// and within the caller's scope, how many mandatory courses they need and
+// how many they have met. $1 region, $2 formation, $3 posting, $4 rank (0
+// means any), $5 and $6 the scope.
+const complianceOfficersCTE = `
+	WITH officer_courses AS (
+		SELECT u.id AS user_id, u.formation_id, cp.course_id, cp.credithours,
+		       COALESCE((
+		           SELECT SUM(us.credithours_completed)
+		           FROM user_session us
+		           INNER JOIN session s ON s.id = us.session_id AND s.deleted_at IS NULL
+		           INNER JOIN course c ON c.id = s.course_id
+		           WHERE us.trainee_id = u.id AND s.course_id = cp.course_id
+		           AND ` + courseCompletionValid + `
+		       ), 0) AS earned
+		FROM users u
+		INNER JOIN formation f ON f.id = u.formation_id
+		LEFT JOIN course_posting cp ON cp.posting_id = u.posting_id
+		      AND cp.rank_id = u.rank_id AND cp.mandatory
+		      AND cp.course_id IN (SELECT id FROM course WHERE deleted_at IS NULL)
+		WHERE u.deleted_at IS NULL
+		  AND ($1 = 0 OR f.region_id = $1)
+		  AND ($2 = 0 OR u.formation_id = $2)
+		  AND ($3 = 0 OR u.posting_id = $3)
+		  AND ($4 = 0 OR u.rank_id = $4)
+		  AND ($5 OR u.formation_id = ANY($6))
+	),
+	officers AS (
+		SELECT user_id, formation_id,
+		       COUNT(course_id) AS required,
+		       COUNT(course_id) FILTER (WHERE earned >= credithours) AS met
+		FROM officer_courses
+		GROUP BY user_id, formation_id
+	)`
+
+// Roll up officer compliance per formation within scope. The formations are
+// paginated, the totals and top missing courses cover every matching officer.
+func (c ComplianceModel) GetReport(regionID, formationID, postingID, rankID int64, scope *AccessScope, filters Filters) (*ComplianceReport, Metadata, error) {
+	report := &ComplianceReport{
+		Formations:        []*FormationCompliance{},
+		TopMissingCourses: []*MissingCourse{},
+	}
+	args := []any{regionID, formationID, postingID, rankID, scope.National, pq.Array(scope.FormationIDs)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := fmt.Sprintf(complianceOfficersCTE+`
+		SELECT COUNT(*) OVER(), f.id AS id, f.formation AS formation, f.region_id,
+		       COUNT(*),
+		       COUNT(*) FILTER (WHERE o.met = o.required),
+		       COUNT(*) FILTER (WHERE o.met > 0 AND o.met < o.required),
+		       COUNT(*) FILTER (WHERE o.met = 0 AND o.required > 0)
+		FROM officers o
+		INNER JOIN formation f ON f.id = o.formation_id
+		GROUP BY f.id, f.formation, f.region_id
+		ORDER BY %s %s, id ASC
+		LIMIT $7 OFFSET $8`, filters.sortColumn(), filters.sortDirection())
+
+	rows, err := c.DB.QueryContext(ctx, query, append(args, filters.limit(), filters.offset())...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	for rows.Next() {
+		var formation FormationCompliance
+		err := rows.Scan(
+			&totalRecords,
+			&formation.FormationID,
+			&formation.Formation,
+			&formation.RegionID,
+			&formation.Officers,
+			&formation.Compliant,
+			&formation.PartiallyCompliant,
+			&formation.NonCompliant,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		report.Formations = append(report.Formations, &formation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	query = complianceOfficersCTE + `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE met = required),
+		       COUNT(*) FILTER (WHERE met > 0 AND met < required),
+		       COUNT(*) FILTER (WHERE met = 0 AND required > 0)
+		FROM officers`
+
+	err = c.DB.QueryRowContext(ctx, query, args...).Scan(
+		&report.Totals.Officers,
+		&report.Totals.Compliant,
+		&report.Totals.PartiallyCompliant,
+		&report.Totals.NonCompliant,
+	)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+
+	query = complianceOfficersCTE + `
+		SELECT c.id, c.course, COUNT(*) AS officers_missing
+		FROM officer_courses oc
+		INNER JOIN course c ON c.id = oc.course_id
+		WHERE oc.earned < oc.credithours
+		GROUP BY c.id, c.course
+		ORDER BY officers_missing DESC, c.id ASC
+		LIMIT 5`
+
+	missingRows, err := c.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer missingRows.Close()
+
+	for missingRows.Next() {
+		var course MissingCourse
+		err := missingRows.Scan(&course.CourseID, &course.Course, &course.OfficersMissing)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		report.TopMissingCourses = append(report.TopMissingCourses, &course)
+	}
+
+	if err = missingRows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return report, metadata, nil
+}
+
+// An officer's qualification in a course with a validity period that is
+// about to lapse
+type ExpiringQualification struct {
+	UserID           int64     `json:"user_id"`
+	RegulationNumber string    `json:"regulation_number"`
+	FName            string    `json:"fname"`
+	LName            string    `json:"lname"`
+	FormationID      int64     `json:"formation_id"`
+	Formation        string    `json:"formation"`
+	CourseID         int64     `json:"course_id"`
+	Course           string    `json:"course"`
+	ExpiresAt        time.Time `json:"expires_at"`
+}
+
+// Qualifications that lapse within the given number of days, judged by each
+// officer's most recent completion of the course so that anyone who has
+// already recertified is left out. Filtered by region and formation (0
+// means any) and limited to officers within scope.
+func (c ComplianceModel) GetExpiring(regionID, formationID int64, withinDays int, scope *AccessScope, filters Filters) ([]*ExpiringQualification, Metadata, error) {
+	query := fmt.Sprintf(`
+		WITH latest AS (
+			SELECT us.trainee_id, s.course_id, MAX(`+courseCompletionExpiry+`) AS expires_at
+			FROM user_session us
+			INNER JOIN session s ON s.id = us.session_id AND s.deleted_at IS NULL
+			INNER JOIN course c ON c.id = s.course_id AND c.deleted_at IS NULL
+			WHERE us.completed AND c.validity_months > 0
+			GROUP BY us.trainee_id, s.course_id
+		)
+		SELECT COUNT(*) OVER(), u.id, u.regulation_number, u.fname, u.lname,
+		       f.id, f.formation, c.id, c.course AS course, l.expires_at AS expires_at
+		FROM latest l
+		INNER JOIN users u ON u.id = l.trainee_id AND u.deleted_at IS NULL
+		INNER JOIN formation f ON f.id = u.formation_id
+		INNER JOIN course c ON c.id = l.course_id
+		WHERE l.expires_at > NOW()
+		AND l.expires_at <= NOW() + make_interval(days => $3)
+		AND ($1 = 0 OR f.region_id = $1)
+		AND ($2 = 0 OR u.formation_id = $2)
+		AND ($4 OR u.formation_id = ANY($5))
+		ORDER BY %s %s, u.id ASC, c.id ASC
+		LIMIT $6 OFFSET $7`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := c.DB.QueryContext(ctx, query, regionID, formationID, withinDays, scope.National, pq.Array(scope.FormationIDs), filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	qualifications := []*ExpiringQualification{}
+	for rows.Next() {
+		var qualification ExpiringQualification
+		err := rows.Scan(
+			&totalRecords,
+			&qualification.UserID,
+			&qualification.RegulationNumber,
+			&qualification.FName,
+			&qualification.LName,
+			&qualification.FormationID,
+			&qualification.Formation,
+			&qualification.CourseID,
+			&qualification.Course,
+			&qualification.ExpiresAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		qualifications = append(qualifications, &qualification)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return qualifications, metadata, nil
+}
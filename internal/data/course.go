@@ -12,12 +12,30 @@ import (
 )
 
 type Course struct {
-	ID          int64     `json:"id"`
-	Course_Name string    `json:"course"`
-	Description string    `json:"description"`
-	CreatedAt   time.Time `json:"-"`
+	ID          int64  `json:"id"`
+	Course_Name string `json:"course"`
+	Description string `json:"description"`
+	// Completion policy used to work out credit hours from attendance
+	MinAttendancePercent int `json:"min_attendance_percent"`
+	HoursPerDay          int `json:"hours_per_day"`
+	// Months a completion stays valid for, 0 if it never expires
+	ValidityMonths int        `json:"validity_months"`
+	CreatedAt      time.Time  `json:"-"`
+	DeletedAt      *time.Time `json:"deleted_at,omitempty"`
 }
 
+// Share of session days a trainee must attend to complete a course, unless
+// the course sets its own
+const DefaultMinAttendancePercent = 80
+
+// When a completion of course c in session s lapses, NULL if it never does.
+// Courses with a validity period are valid for validity_months after the
+// session ends.
+const courseCompletionExpiry = `CASE WHEN c.validity_months > 0 THEN s.ends_at + make_interval(months => c.validity_months) END`
+
+// Whether hours earned in session s of course c still count
+const courseCompletionValid = `(c.validity_months = 0 OR s.ends_at + make_interval(months => c.validity_months) > NOW())`
+
 // Performs the validation checks
 func ValidateCourse(v *validator.Validator, course *Course) {
 	// check if the Course name field is empty
@@ -28,6 +46,11 @@ func ValidateCourse(v *validator.Validator, course *Course) {
 	v.Check(len(course.Description) <= 100, "descrption", "must not be more than 100 bytes long")
 	// check if the Author field is empty
 	v.Check(len(course.Course_Name) <= 25, "course", "must not be more than 25 bytes long")
+	// check the completion policy is in range
+	v.Check(course.MinAttendancePercent >= 0 && course.MinAttendancePercent <= 100, "min_attendance_percent", "must be between 0 and 100")
+	v.Check(course.HoursPerDay >= 0, "hours_per_day", "must not be negative")
+	v.Check(course.ValidityMonths >= 0, "validity_months", "must not be negative")
+	v.Check(course.ValidityMonths <= 600, "validity_months", "must not be more than 600")
 }
 
 type CourseModel struct {
@@ -37,12 +60,12 @@ type CourseModel struct {
 // Insert new course into the database
 func (c CourseModel) Insert(course *Course) error {
 	query := `
-		INSERT INTO course (course, description)
-		VALUES ($1, $2)
+		INSERT INTO course (course, description, min_attendance_percent, hours_per_day, validity_months)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING id, created_at`
 
-	// values to replace $1 and $2
-	args := []any{course.Course_Name, course.Description}
+	// values to replace $1 to $5
+	args := []any{course.Course_Name, course.Description, course.MinAttendancePercent, course.HoursPerDay, course.ValidityMonths}
 
 	// Context with a 3-second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -52,8 +75,9 @@ func (c CourseModel) Insert(course *Course) error {
 	return c.DB.QueryRowContext(ctx, query, args...).Scan(&course.ID, &course.CreatedAt)
 }
 
-// Get a specific course from the database
-func (c CourseModel) Get(id int64) (*Course, error) {
+// Get a specific course from the database. Deleted courses are reported as
+// not found unless includeDeleted is set.
+func (c CourseModel) Get(id int64, includeDeleted bool) (*Course, error) {
 	// Check if the id is valid
 	if id < 1 {
 		return nil, ErrRecordNotFound
@@ -61,9 +85,10 @@ func (c CourseModel) Get(id int64) (*Course, error) {
 
 	// the SQL query to be executed
 	query := `
-		SELECT id, course, description, created_at
+		SELECT id, course, description, min_attendance_percent, hours_per_day, validity_months, created_at, deleted_at
 		FROM course
-		WHERE id = $1`
+		WHERE id = $1
+		AND ($2 OR deleted_at IS NULL)`
 
 	// course variable to hold the data returned by the query
 	var course Course
@@ -73,11 +98,15 @@ func (c CourseModel) Get(id int64) (*Course, error) {
 	defer cancel()
 
 	// execute the query against the database
-	err := c.DB.QueryRowContext(ctx, query, id).Scan(
+	err := c.DB.QueryRowContext(ctx, query, id, includeDeleted).Scan(
 		&course.ID,
 		&course.Course_Name,
 		&course.Description,
+		&course.MinAttendancePercent,
+		&course.HoursPerDay,
+		&course.ValidityMonths,
 		&course.CreatedAt,
+		&course.DeletedAt,
 	)
 
 	if err != nil {
@@ -97,13 +126,13 @@ func (c CourseModel) Update(course *Course) error {
 	// the SQL query to be executed
 	query := `
 		UPDATE course
-		SET course = $1, description = $2
-		WHERE id = $3
-		RETURNING id, course, description, created_at
+		SET course = $1, description = $2, min_attendance_percent = $3, hours_per_day = $4, validity_months = $5
+		WHERE id = $6
+		RETURNING id, course, description, min_attendance_percent, hours_per_day, validity_months, created_at
 		`
 
-	// values to replace $1, $2, and $3
-	args := []any{course.Course_Name, course.Description, course.ID}
+	// values to replace $1 to $6
+	args := []any{course.Course_Name, course.Description, course.MinAttendancePercent, course.HoursPerDay, course.ValidityMonths, course.ID}
 
 	// Context with a 3-second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -113,11 +142,15 @@ func (c CourseModel) Update(course *Course) error {
 		&course.ID,
 		&course.Course_Name,
 		&course.Description,
+		&course.MinAttendancePercent,
+		&course.HoursPerDay,
+		&course.ValidityMonths,
 		&course.CreatedAt,
 	)
 }
 
-// Delete a specific course from the database
+// Mark a course as deleted. It keeps its sessions and history and can be
+// restored until it is purged.
 func (c CourseModel) Delete(id int64) error {
 
 	// Check
@@ -127,8 +160,10 @@ func (c CourseModel) Delete(id int64) error {
 
 	// the SQL query to be executed
 	query := `
-		DELETE FROM course
+		UPDATE course
+		SET deleted_at = NOW()
 		WHERE id = $1
+		AND deleted_at IS NULL
 		`
 	// Context with a 3-second timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
@@ -153,21 +188,76 @@ func (c CourseModel) Delete(id int64) error {
 	return nil
 }
 
-// Get all courses from the database
-func (c CourseModel) GetAll(course string, description string, filters Filters) ([]*Course, Metadata, error) {
+// Bring back a deleted course
+func (c CourseModel) Restore(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		UPDATE course
+		SET deleted_at = NULL
+		WHERE id = $1
+		AND deleted_at IS NOT NULL
+		`
+
+	// Context with a 3-second timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := c.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+	return nil
+}
+
+// Permanently remove courses deleted before the cutoff, along with their
+// sessions. Returns how many were removed.
+func (c CourseModel) Purge(deletedBefore time.Time) (int64, error) {
+	query := `
+		DELETE FROM course
+		WHERE deleted_at < $1
+		`
+
+	// Context with a 3-second timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := c.DB.ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Get all courses from the database. Deleted courses are left out unless
+// includeDeleted is set.
+func (c CourseModel) GetAll(course string, description string, includeDeleted bool, filters Filters) ([]*Course, Metadata, error) {
 	// the SQL query to be executed
 	query := fmt.Sprintf(`
-		SELECT COUNT(*) OVER(), id, course, description, created_at
+		SELECT COUNT(*) OVER(), id, course, description, min_attendance_percent, hours_per_day, validity_months, created_at, deleted_at
 		FROM course
 		WHERE (to_tsvector('simple', course) @@ plainto_tsquery('simple', $1) OR $1 = '')
 		AND (to_tsvector('simple', description) @@ plainto_tsquery('simple', $2) OR $2 = '')
+		AND ($5 OR deleted_at IS NULL)
 		ORDER BY %s %s, id ASC
 		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	rows, err := c.DB.QueryContext(ctx, query, course, description, filters.limit(), filters.offset())
+	rows, err := c.DB.QueryContext(ctx, query, course, description, filters.limit(), filters.offset(), includeDeleted)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
@@ -183,7 +273,11 @@ func (c CourseModel) GetAll(course string, description string, filters Filters)
 			&course.ID,
 			&course.Course_Name,
 			&course.Description,
+			&course.MinAttendancePercent,
+			&course.HoursPerDay,
+			&course.ValidityMonths,
 			&course.CreatedAt,
+			&course.DeletedAt,
 		)
 		if err != nil {
 			return nil, Metadata{}, err
@@ -0,0 +1,299 @@
+// Filename: internal/data/course_prerequisite.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Something a trainee needs before they can take a course: either another
+// course completed or at least a given rank. Ranks are compared on their
+// seniority.
+type CoursePrerequisite struct {
+	ID               int64     `json:"id"`
+	CourseID         int64     `json:"course_id"`
+	RequiredCourseID int64     `json:"required_course_id,omitempty"`
+	RequiredCourse   string    `json:"required_course,omitempty"`
+	MinRankID        int64     `json:"min_rank_id,omitempty"`
+	MinRank          string    `json:"min_rank,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// Performs the validation checks
+func ValidateCoursePrerequisite(v *validator.Validator, prerequisite *CoursePrerequisite) {
+	v.Check(prerequisite.RequiredCourseID >= 0, "required_course_id", "must not be negative")
+	v.Check(prerequisite.MinRankID >= 0, "min_rank_id", "must not be negative")
+	v.Check((prerequisite.RequiredCourseID > 0) != (prerequisite.MinRankID > 0), "prerequisite", "must set exactly one of required_course_id or min_rank_id")
+	v.Check(prerequisite.RequiredCourseID != prerequisite.CourseID, "required_course_id", "must not be the course itself")
+}
+
+// What the trainee still needs, in the words used in error messages
+func (p *CoursePrerequisite) String() string {
+	if p.RequiredCourseID > 0 {
+		return fmt.Sprintf("completion of %s", p.RequiredCourse)
+	}
+	return fmt.Sprintf("rank of %s or above", p.MinRank)
+}
+
+// Whether a trainee can take a course and, if not, what they are missing
+type Eligibility struct {
+	CourseID int64                 `json:"course_id"`
+	UserID   int64                 `json:"user_id"`
+	Eligible bool                  `json:"eligible"`
+	Missing  []*CoursePrerequisite `json:"missing"`
+}
+
+// A readable list of the missing prerequisites
+func (e *Eligibility) MissingSummary() string {
+	missing := make([]string, 0, len(e.Missing))
+	for _, prerequisite := range e.Missing {
+		missing = append(missing, prerequisite.String())
+	}
+	return strings.Join(missing, "; ")
+}
+
+type CoursePrerequisiteModel struct {
+	DB *sql.DB
+}
+
+// Add a prerequisite to a course. Required courses can't lead back to the
+// course itself or nobody could ever take either of them.
+func (m CoursePrerequisiteModel) Insert(prerequisite *CoursePrerequisite) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	if prerequisite.RequiredCourseID > 0 {
+		var cycle bool
+		query := `
+			WITH RECURSIVE required (course_id) AS (
+				SELECT $1::bigint
+				UNION
+				SELECT p.required_course_id
+				FROM course_prerequisite p
+				INNER JOIN required r ON r.course_id = p.course_id
+				WHERE p.required_course_id IS NOT NULL
+			)
+			SELECT EXISTS (SELECT 1 FROM required WHERE course_id = $2)`
+
+		err := m.DB.QueryRowContext(ctx, query, prerequisite.RequiredCourseID, prerequisite.CourseID).Scan(&cycle)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrPrerequisiteCycle
+		}
+	}
+
+	query := `
+		INSERT INTO course_prerequisite (course_id, required_course_id, min_rank_id)
+		VALUES ($1, NULLIF($2, 0), NULLIF($3, 0))
+		RETURNING id, created_at`
+
+	args := []any{prerequisite.CourseID, prerequisite.RequiredCourseID, prerequisite.MinRankID}
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&prerequisite.ID, &prerequisite.CreatedAt)
+	if err != nil {
+		switch {
+		case isUniqueViolation(err):
+			return ErrDuplicatePrerequisite
+		case isForeignKeyViolation(err) && prerequisite.MinRankID > 0:
+			return ErrRankNotFound
+		case isForeignKeyViolation(err):
+			return ErrCourseNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Every prerequisite of a course, required courses first
+func (m CoursePrerequisiteModel) GetAllForCourse(courseID int64) ([]*CoursePrerequisite, error) {
+	query := `
+		SELECT p.id, p.course_id, COALESCE(p.required_course_id, 0), COALESCE(c.course, ''),
+		       COALESCE(p.min_rank_id, 0), COALESCE(r.title, ''), p.created_at
+		FROM course_prerequisite p
+		LEFT JOIN course c ON c.id = p.required_course_id
+		LEFT JOIN rank r ON r.id = p.min_rank_id
+		WHERE p.course_id = $1
+		ORDER BY r.seniority NULLS FIRST, c.course ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, courseID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prerequisites := []*CoursePrerequisite{}
+	for rows.Next() {
+		var prerequisite CoursePrerequisite
+		err := rows.Scan(
+			&prerequisite.ID,
+			&prerequisite.CourseID,
+			&prerequisite.RequiredCourseID,
+			&prerequisite.RequiredCourse,
+			&prerequisite.MinRankID,
+			&prerequisite.MinRank,
+			&prerequisite.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		prerequisites = append(prerequisites, &prerequisite)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return prerequisites, nil
+}
+
+// Remove one of a course's prerequisites
+func (m CoursePrerequisiteModel) Delete(courseID, id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM course_prerequisite
+		WHERE id = $1 AND course_id = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id, courseID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Check a trainee against every prerequisite of a course. A course counts as
+// completed once any session of it has been. Prerequisites on deleted
+// courses no longer apply, and an officer without a rank meets no minimum.
+func (m CoursePrerequisiteModel) CheckEligibility(courseID, userID int64) (*Eligibility, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return checkEligibility(ctx, m.DB, courseID, userID)
+}
+
+// Does the eligibility check on db, which may be a transaction that is
+// about to enrol the trainee
+func checkEligibility(ctx context.Context, db execer, courseID, userID int64) (*Eligibility, error) {
+	query := `
+		SELECT p.id, p.course_id, COALESCE(p.required_course_id, 0), COALESCE(c.course, ''),
+		       COALESCE(p.min_rank_id, 0), COALESCE(r.title, ''), p.created_at,
+		       CASE
+		           WHEN p.required_course_id IS NOT NULL THEN EXISTS (
+		               SELECT 1
+		               FROM user_session us
+		               INNER JOIN session s ON s.id = us.session_id
+		               WHERE us.trainee_id = $2
+		               AND us.completed
+		               AND s.course_id = p.required_course_id
+		           )
+		           ELSE COALESCE((
+		               SELECT ur.seniority
+		               FROM users u
+		               INNER JOIN rank ur ON ur.id = u.rank_id
+		               WHERE u.id = $2
+		           ), 0) >= r.seniority
+		       END
+		FROM course_prerequisite p
+		LEFT JOIN course c ON c.id = p.required_course_id
+		LEFT JOIN rank r ON r.id = p.min_rank_id
+		WHERE p.course_id = $1
+		AND (p.required_course_id IS NULL OR c.deleted_at IS NULL)
+		ORDER BY r.seniority NULLS FIRST, c.course ASC`
+
+	rows, err := db.QueryContext(ctx, query, courseID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	eligibility := &Eligibility{
+		CourseID: courseID,
+		UserID:   userID,
+		Missing:  []*CoursePrerequisite{},
+	}
+	for rows.Next() {
+		var prerequisite CoursePrerequisite
+		var met bool
+		err := rows.Scan(
+			&prerequisite.ID,
+			&prerequisite.CourseID,
+			&prerequisite.RequiredCourseID,
+			&prerequisite.RequiredCourse,
+			&prerequisite.MinRankID,
+			&prerequisite.MinRank,
+			&prerequisite.CreatedAt,
+			&met,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if !met {
+			eligibility.Missing = append(eligibility.Missing, &prerequisite)
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	eligibility.Eligible = len(eligibility.Missing) == 0
+
+	return eligibility, nil
+}
+
+// Look up the course a session runs and check the trainee against it
+func (m CoursePrerequisiteModel) CheckEligibilityForSession(sessionID, userID int64) (*Eligibility, error) {
+	if sessionID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	var courseID int64
+	query := `
+		SELECT course_id
+		FROM session
+		WHERE id = $1
+		AND deleted_at IS NULL`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, sessionID).Scan(&courseID)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return m.CheckEligibility(courseID, userID)
+}
@@ -0,0 +1,247 @@
+// Filename: internal/data/email_outbox.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Where an email is in the outbox. Failed emails have run out of attempts
+// and stay put until they are resent.
+const (
+	EmailStatusPending = "pending"
+	EmailStatusSent    = "sent"
+	EmailStatusFailed  = "failed"
+)
+
+var EmailStatuses = []string{EmailStatusPending, EmailStatusSent, EmailStatusFailed}
+
+// An email waiting in, or sent from, the outbox
+type Email struct {
+	ID        int64  `json:"id"`
+	Recipient string `json:"recipient"`
+	Template  string `json:"template"`
+	// Values for the template. They can include tokens so they are never
+	// shown and are cleared once the email has been sent.
+	Data          map[string]any `json:"-"`
+	Status        string         `json:"status"`
+	Attempts      int            `json:"attempts"`
+	LastError     string         `json:"last_error,omitempty"`
+	NextAttemptAt time.Time      `json:"next_attempt_at"`
+	SentAt        *time.Time     `json:"sent_at,omitempty"`
+	CreatedAt     time.Time      `json:"created_at"`
+}
+
+type EmailOutboxModel struct {
+	DB *sql.DB
+}
+
+// Add an email to the outbox using db, which can be the transaction making
+// the change the email is about
+func enqueueEmail(ctx context.Context, db execer, email *Email) error {
+	data, err := json.Marshal(email.Data)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO email_outbox (recipient, template, data)
+		VALUES ($1, $2, $3)`
+
+	_, err = db.ExecContext(ctx, query, email.Recipient, email.Template, data)
+	return err
+}
+
+// Add an email to the outbox on its own
+func (m EmailOutboxModel) Enqueue(email *Email) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return enqueueEmail(ctx, m.DB, email)
+}
+
+// The columns scanned by scanEmail
+const emailColumns = `id, recipient, template, data, status, attempts, last_error, next_attempt_at, sent_at, created_at`
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanEmail(row rowScanner, prefix ...any) (*Email, error) {
+	var email Email
+	var data []byte
+	dest := append(prefix,
+		&email.ID,
+		&email.Recipient,
+		&email.Template,
+		&data,
+		&email.Status,
+		&email.Attempts,
+		&email.LastError,
+		&email.NextAttemptAt,
+		&email.SentAt,
+		&email.CreatedAt,
+	)
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &email.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &email, nil
+}
+
+// Take up to limit pending emails that are due. They are pushed back by
+// lease while they are being sent so that other workers leave them alone,
+// and so that they are picked up again if this one dies part way through.
+func (m EmailOutboxModel) ClaimDue(limit int, lease time.Duration) ([]*Email, error) {
+	query := `
+		UPDATE email_outbox
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		WHERE id IN (
+			SELECT id
+			FROM email_outbox
+			WHERE status = 'pending' AND next_attempt_at <= NOW()
+			ORDER BY next_attempt_at ASC, id ASC
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING ` + emailColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	emails := []*Email{}
+	for rows.Next() {
+		email, err := scanEmail(rows)
+		if err != nil {
+			return nil, err
+		}
+		emails = append(emails, email)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return emails, nil
+}
+
+// Mark an email as sent and drop its template values
+func (m EmailOutboxModel) MarkSent(id int64) error {
+	query := `
+		UPDATE email_outbox
+		SET status = 'sent', attempts = attempts + 1, last_error = '', data = '{}', sent_at = NOW()
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, id)
+	return err
+}
+
+// Record a failed attempt. The email is tried again at nextAttemptAt, or
+// moved to failed when deadLetter is set.
+func (m EmailOutboxModel) RecordFailure(id int64, lastError string, nextAttemptAt time.Time, deadLetter bool) error {
+	query := `
+		UPDATE email_outbox
+		SET attempts = attempts + 1, last_error = $2, next_attempt_at = $3,
+		    status = CASE WHEN $4 THEN 'failed' ELSE status END
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := m.DB.ExecContext(ctx, query, id, lastError, nextAttemptAt, deadLetter)
+	return err
+}
+
+// Put a failed email back in the queue with a fresh set of attempts
+func (m EmailOutboxModel) Resend(id int64) (*Email, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		UPDATE email_outbox
+		SET status = 'pending', attempts = 0, last_error = '', next_attempt_at = NOW()
+		WHERE id = $1 AND status = 'failed'
+		RETURNING ` + emailColumns
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	email, err := scanEmail(m.DB.QueryRowContext(ctx, query, id))
+	if err == nil {
+		return email, nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+
+	// Work out whether the email is missing or just hasn't failed
+	var exists bool
+	err = m.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM email_outbox WHERE id = $1)`, id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrEmailNotFailed
+	}
+
+	return nil, ErrRecordNotFound
+}
+
+// List outbox emails, optionally only those with the given status
+func (m EmailOutboxModel) GetAll(status string, filters Filters) ([]*Email, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), `+emailColumns+`
+		FROM email_outbox
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY %s %s, id DESC
+		LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, status, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	emails := []*Email{}
+
+	for rows.Next() {
+		email, err := scanEmail(rows, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		emails = append(emails, email)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return emails, metadata, nil
+}
@@ -4,6 +4,8 @@ package data
 
 import (
 	"errors"
+
+	"github.com/lib/pq"
 )
 
 var ErrRecordNotFound = errors.New("record not found")
@@ -12,3 +14,56 @@ var ErrEditConflict = errors.New("edit Conflict")
 var ErrCourseNotFound = errors.New("course not found")
 var ErrPostingNotFound = errors.New("posting not found")
 var ErrRankNotFound = errors.New("rank not found")
+var ErrRegionNotFound = errors.New("region not found")
+var ErrPermissionNotFound = errors.New("permission not found")
+
+// Returned when a delete is blocked because other rows still reference the record
+var ErrRecordInUse = errors.New("record in use")
+
+// Returned when an officer tries to enrol in a session they are already
+// enrolled or waitlisted for
+var ErrAlreadyEnrolled = errors.New("already enrolled")
+
+// Returned when an admin adds a trainee to a session that has no seats left
+var ErrSessionFull = errors.New("session full")
+
+// Returned when enrolment changes are attempted on a session that is no longer planned
+var ErrSessionClosed = errors.New("session closed")
+
+// Returned when attendance has already been recorded for a trainee on that date
+var ErrDuplicateAttendance = errors.New("duplicate attendance")
+
+// Returned when a roll-call includes a user session that belongs to another session
+var ErrNotEnrolled = errors.New("not enrolled in session")
+
+// Returned when a trainee has already rated the facilitator of a session
+var ErrDuplicateRating = errors.New("duplicate rating")
+
+// Returned when a rating names someone other than the session's facilitator
+var ErrNotFacilitator = errors.New("not the session facilitator")
+
+// Returned when a trainee has already answered the evaluation for a session
+var ErrDuplicateResponse = errors.New("duplicate survey response")
+
+// Returned when a course already has the prerequisite being added
+var ErrDuplicatePrerequisite = errors.New("duplicate prerequisite")
+
+// Returned when a required course would end up requiring the course itself
+var ErrPrerequisiteCycle = errors.New("prerequisite cycle")
+
+// Returned when resending an email that hasn't been moved to failed
+var ErrEmailNotFailed = errors.New("email not failed")
+
+// Check if PostgreSQL rejected the query because of a foreign key constraint
+// (SQLSTATE 23503 foreign_key_violation)
+func isForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23503"
+}
+
+// Check if PostgreSQL rejected the query because of a unique constraint
+// (SQLSTATE 23505 unique_violation)
+func isUniqueViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505"
+}
@@ -11,17 +11,27 @@ import (
 
 )
 
+// A trainee's rating of the facilitator (UserID) who ran a session.
+// Ratings made before they were linked to sessions have no SessionID or
+// RaterID. The rater is never sent back so facilitators can't see who
+// rated them.
 type FacilitatorRating struct {
     ID        int64     `json:"id" ` 
     UserID    int64     `json:"user_id"`
+    SessionID int64     `json:"session_id"`
+    RaterID   int64     `json:"-"`
     Rating    int       `json:"rating"`
+    Comment   string    `json:"comment,omitempty"`
     CreatedAt time.Time `json:"-"`
 }
 
-// Validator function for facilitator ratings
+// Validator function for facilitator ratings. The facilitator is taken
+// from the session so UserID is optional.
 func ValidateFacilitatorRating(v *validator.Validator, fr *FacilitatorRating) {
-    v.Check(fr.UserID > 0, "user_id", "must be provided")
+    v.Check(fr.UserID >= 0, "user_id", "must be a valid user id")
+    v.Check(fr.SessionID > 0, "session_id", "must be provided")
     v.Check(fr.Rating >= 1 && fr.Rating <= 5, "rating", "must be between 1 and 5")
+    v.Check(len(fr.Comment) <= 500, "comment", "must not be more than 500 bytes long")
 }
 
 // Setup model
@@ -30,18 +40,67 @@ type FacilitatorRatingModel struct {
 }
 
 
+// Save a rating for the facilitator of a session. The rater must have taken
+// part in the session and can only rate it once. UserID is filled in from
+// the session when it is 0.
 func (f FacilitatorRatingModel) Insert(fr *FacilitatorRating) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    tx, err := f.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    var facilitatorID int64
     query := `
-        INSERT INTO facilitator_rating (user_id, rating)
-        VALUES ($1, $2)
+        SELECT COALESCE(facilitator_id, 0)
+        FROM session
+        WHERE id = $1
+        AND deleted_at IS NULL
+    `
+    err = tx.QueryRowContext(ctx, query, fr.SessionID).Scan(&facilitatorID)
+    if err != nil {
+        if errors.Is(err, sql.ErrNoRows) {
+            return ErrRecordNotFound
+        }
+        return err
+    }
+
+    if fr.UserID == 0 {
+        fr.UserID = facilitatorID
+    }
+    if facilitatorID == 0 || fr.UserID != facilitatorID {
+        return ErrNotFacilitator
+    }
+
+    var enrolled bool
+    query = `SELECT EXISTS (SELECT 1 FROM user_session WHERE session_id = $1 AND trainee_id = $2)`
+    err = tx.QueryRowContext(ctx, query, fr.SessionID, fr.RaterID).Scan(&enrolled)
+    if err != nil {
+        return err
+    }
+    if !enrolled {
+        return ErrNotEnrolled
+    }
+
+    query = `
+        INSERT INTO facilitator_rating (user_id, session_id, rater_id, rating, comment)
+        VALUES ($1, $2, $3, $4, $5)
         RETURNING id, created_at
     `
-    args := []any{fr.UserID, fr.Rating}
+    args := []any{fr.UserID, fr.SessionID, fr.RaterID, fr.Rating, fr.Comment}
 
-    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-    defer cancel()
+    err = tx.QueryRowContext(ctx, query, args...).Scan(&fr.ID, &fr.CreatedAt)
+    if err != nil {
+        if isUniqueViolation(err) {
+            return ErrDuplicateRating
+        }
+        return err
+    }
 
-    return f.DB.QueryRowContext(ctx, query, args...).Scan(&fr.ID, &fr.CreatedAt)
+    return tx.Commit()
 }
 
 
@@ -51,7 +110,7 @@ func (f FacilitatorRatingModel) Get(id int64) (*FacilitatorRating, error) {
     }
 
     query := `
-        SELECT id, user_id, rating, created_at
+        SELECT id, user_id, COALESCE(session_id, 0), COALESCE(rater_id, 0), rating, comment, created_at
         FROM facilitator_rating
         WHERE id = $1
     `
@@ -61,7 +120,7 @@ func (f FacilitatorRatingModel) Get(id int64) (*FacilitatorRating, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    err := f.DB.QueryRowContext(ctx, query, id).Scan(&fr.ID, &fr.UserID, &fr.Rating, &fr.CreatedAt)
+    err := f.DB.QueryRowContext(ctx, query, id).Scan(&fr.ID, &fr.UserID, &fr.SessionID, &fr.RaterID, &fr.Rating, &fr.Comment, &fr.CreatedAt)
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
             return nil, ErrRecordNotFound
@@ -72,11 +131,14 @@ func (f FacilitatorRatingModel) Get(id int64) (*FacilitatorRating, error) {
     return &fr, nil
 }
 
-func (f FacilitatorRatingModel) GetAll(userID int64, filters Filters) ([]*FacilitatorRating, Metadata, error) {
+// List ratings, optionally only those for one facilitator or session
+// (0 means any)
+func (f FacilitatorRatingModel) GetAll(userID, sessionID int64, filters Filters) ([]*FacilitatorRating, Metadata, error) {
     query := fmt.Sprintf(`
-        SELECT COUNT(*) OVER(), id, user_id, rating, created_at
+        SELECT COUNT(*) OVER(), id, user_id, COALESCE(session_id, 0), COALESCE(rater_id, 0), rating, comment, created_at
         FROM facilitator_rating
         WHERE ($1 = 0 OR user_id = $1)
+        AND ($4 = 0 OR session_id = $4)
         ORDER BY %s %s, id ASC
         LIMIT $2 OFFSET $3
     `, filters.sortColumn(), filters.sortDirection())
@@ -84,7 +146,7 @@ func (f FacilitatorRatingModel) GetAll(userID int64, filters Filters) ([]*Facili
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    rows, err := f.DB.QueryContext(ctx, query, userID, filters.limit(), filters.offset())
+    rows, err := f.DB.QueryContext(ctx, query, userID, filters.limit(), filters.offset(), sessionID)
     if err != nil {
         return nil, Metadata{}, err
     }
@@ -95,7 +157,7 @@ func (f FacilitatorRatingModel) GetAll(userID int64, filters Filters) ([]*Facili
 
     for rows.Next() {
         var fr FacilitatorRating
-        err := rows.Scan(&totalRecords, &fr.ID, &fr.UserID, &fr.Rating, &fr.CreatedAt)
+        err := rows.Scan(&totalRecords, &fr.ID, &fr.UserID, &fr.SessionID, &fr.RaterID, &fr.Rating, &fr.Comment, &fr.CreatedAt)
         if err != nil {
             return nil, Metadata{}, err
         }
@@ -109,3 +171,83 @@ func (f FacilitatorRatingModel) GetAll(userID int64, filters Filters) ([]*Facili
     metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
     return ratings, metadata, nil
 }
+
+// How a facilitator has been rated for one course
+type CourseRatingSummary struct {
+    CourseID int64   `json:"course_id"`
+    Course   string  `json:"course"`
+    Count    int     `json:"count"`
+    Average  float64 `json:"average"`
+    // Number of ratings given for each score from 1 to 5
+    Distribution map[int]int `json:"distribution"`
+}
+
+// A facilitator's ratings rolled up per course. Ratings that aren't linked
+// to a session can't be placed under a course and are left out.
+type RatingSummary struct {
+    FacilitatorID int64                  `json:"facilitator_id"`
+    Count         int                    `json:"count"`
+    Average       float64                `json:"average"`
+    Courses       []*CourseRatingSummary `json:"courses"`
+}
+
+func (f FacilitatorRatingModel) GetSummary(facilitatorID int64) (*RatingSummary, error) {
+    query := `
+        SELECT c.id, c.course, COUNT(*), AVG(fr.rating)::float8,
+               COUNT(*) FILTER (WHERE fr.rating = 1),
+               COUNT(*) FILTER (WHERE fr.rating = 2),
+               COUNT(*) FILTER (WHERE fr.rating = 3),
+               COUNT(*) FILTER (WHERE fr.rating = 4),
+               COUNT(*) FILTER (WHERE fr.rating = 5)
+        FROM facilitator_rating fr
+        INNER JOIN session s ON s.id = fr.session_id
+        INNER JOIN course c ON c.id = s.course_id
+        WHERE fr.user_id = $1
+        GROUP BY c.id, c.course
+        ORDER BY c.course ASC
+    `
+
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    rows, err := f.DB.QueryContext(ctx, query, facilitatorID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    summary := &RatingSummary{
+        FacilitatorID: facilitatorID,
+        Courses:       []*CourseRatingSummary{},
+    }
+    total := 0.0
+
+    for rows.Next() {
+        var course CourseRatingSummary
+        var scores [5]int
+        err := rows.Scan(&course.CourseID, &course.Course, &course.Count, &course.Average,
+            &scores[0], &scores[1], &scores[2], &scores[3], &scores[4])
+        if err != nil {
+            return nil, err
+        }
+
+        course.Distribution = make(map[int]int, len(scores))
+        for i, n := range scores {
+            course.Distribution[i+1] = n
+        }
+
+        summary.Count += course.Count
+        total += course.Average * float64(course.Count)
+        summary.Courses = append(summary.Courses, &course)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+
+    if summary.Count > 0 {
+        summary.Average = total / float64(summary.Count)
+    }
+
+    return summary, nil
+}
@@ -0,0 +1,195 @@
+// Filename: internal/data/formation.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+type Formation struct {
+	ID        int64     `json:"id"`
+	RegionID  int64     `json:"region_id"`
+	Formation string    `json:"formation"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// Performs the validation checks
+func ValidateFormation(v *validator.Validator, formation *Formation) {
+	v.Check(formation.RegionID > 0, "region_id", "must be provided and greater than zero")
+	v.Check(formation.Formation != "", "formation", "must be provided")
+	v.Check(len(formation.Formation) <= 100, "formation", "must not be more than 100 bytes long")
+}
+
+type FormationModel struct {
+	DB *sql.DB
+}
+
+// Insert a new formation into the database
+func (f FormationModel) Insert(formation *Formation) error {
+	query := `
+		INSERT INTO formation (region_id, formation)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	args := []any{formation.RegionID, formation.Formation}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := f.DB.QueryRowContext(ctx, query, args...).Scan(&formation.ID, &formation.CreatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrRegionNotFound
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Get a specific formation from the database
+func (f FormationModel) Get(id int64) (*Formation, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, region_id, formation, created_at
+		FROM formation
+		WHERE id = $1`
+
+	var formation Formation
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := f.DB.QueryRowContext(ctx, query, id).Scan(
+		&formation.ID,
+		&formation.RegionID,
+		&formation.Formation,
+		&formation.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &formation, nil
+}
+
+// Update a specific formation in the database
+func (f FormationModel) Update(formation *Formation) error {
+	query := `
+		UPDATE formation
+		SET region_id = $1, formation = $2
+		WHERE id = $3
+		RETURNING created_at`
+
+	args := []any{formation.RegionID, formation.Formation, formation.ID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := f.DB.QueryRowContext(ctx, query, args...).Scan(&formation.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		case isForeignKeyViolation(err):
+			return ErrRegionNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete a specific formation from the database. Officers are attached to a
+// formation with ON DELETE RESTRICT so the delete is refused while any remain.
+func (f FormationModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM formation
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := f.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrRecordInUse
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Get all formations from the database, optionally limited to one region
+func (f FormationModel) GetAll(regionID int64, formation string, filters Filters) ([]*Formation, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), id, region_id, formation, created_at
+		FROM formation
+		WHERE ($1 = 0 OR region_id = $1)
+		AND (to_tsvector('simple', formation) @@ plainto_tsquery('simple', $2) OR $2 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := f.DB.QueryContext(ctx, query, regionID, formation, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	formations := []*Formation{}
+
+	for rows.Next() {
+		var formation Formation
+		err := rows.Scan(
+			&totalRecords,
+			&formation.ID,
+			&formation.RegionID,
+			&formation.Formation,
+			&formation.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		formations = append(formations, &formation)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return formations, metadata, nil
+}
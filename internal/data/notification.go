@@ -0,0 +1,198 @@
+// Filename: internal/data/notification.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// The kinds of scheduled reminder emails
+const (
+	NotificationSessionReminder       = "session_reminder"
+	NotificationQualificationExpiring = "qualification_expiring"
+)
+
+// Who a session reminder is going to
+const (
+	ReminderRoleTrainee     = "trainee"
+	ReminderRoleFacilitator = "facilitator"
+)
+
+// A reminder email that is due. ReferenceID is the session for session
+// reminders and the completed user session for expiry notices.
+type Reminder struct {
+	Kind        string
+	UserID      int64
+	ReferenceID int64
+	Email       string
+	FName       string
+	Role        string
+	Course      string
+	Venue       string
+	StartsAt    time.Time
+	ExpiresAt   time.Time
+}
+
+type NotificationModel struct {
+	DB *sql.DB
+}
+
+// Trainees enrolled in, and facilitators of, planned sessions starting
+// within lead that haven't been reminded yet
+func (m NotificationModel) DueSessionReminders(lead time.Duration) ([]*Reminder, error) {
+	query := `
+		WITH recipients AS (
+			SELECT us.trainee_id AS user_id, s.id AS session_id, 'trainee' AS role
+			FROM session s
+			INNER JOIN user_session us ON us.session_id = s.id
+			WHERE s.status = 'planned' AND s.deleted_at IS NULL
+			AND s.starts_at > NOW() AND s.starts_at <= NOW() + make_interval(secs => $2)
+			UNION
+			SELECT s.facilitator_id, s.id, 'facilitator'
+			FROM session s
+			WHERE s.facilitator_id IS NOT NULL
+			AND s.status = 'planned' AND s.deleted_at IS NULL
+			AND s.starts_at > NOW() AND s.starts_at <= NOW() + make_interval(secs => $2)
+		)
+		SELECT u.id, s.id, u.email, u.fname, r.role, c.course, s.venue, s.starts_at
+		FROM recipients r
+		INNER JOIN users u ON u.id = r.user_id AND u.deleted_at IS NULL
+		INNER JOIN session s ON s.id = r.session_id
+		INNER JOIN course c ON c.id = s.course_id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM notifications_sent n
+			WHERE n.kind = $1 AND n.user_id = u.id AND n.reference_id = s.id
+		)
+		ORDER BY s.starts_at ASC, u.id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, NotificationSessionReminder, lead.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*Reminder{}
+	for rows.Next() {
+		reminder := Reminder{Kind: NotificationSessionReminder}
+		err := rows.Scan(
+			&reminder.UserID,
+			&reminder.ReferenceID,
+			&reminder.Email,
+			&reminder.FName,
+			&reminder.Role,
+			&reminder.Course,
+			&reminder.Venue,
+			&reminder.StartsAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, &reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// Officers whose most recent completion of a course with a validity period
+// lapses within window and who haven't been told about it yet
+func (m NotificationModel) DueExpiryNotices(window time.Duration) ([]*Reminder, error) {
+	query := `
+		WITH latest AS (
+			SELECT DISTINCT ON (us.trainee_id, s.course_id)
+			       us.id, us.trainee_id, c.course, ` + courseCompletionExpiry + ` AS expires_at
+			FROM user_session us
+			INNER JOIN session s ON s.id = us.session_id
+			INNER JOIN course c ON c.id = s.course_id AND c.deleted_at IS NULL
+			WHERE us.completed AND c.validity_months > 0
+			ORDER BY us.trainee_id, s.course_id, s.ends_at DESC
+		)
+		SELECT u.id, l.id, u.email, u.fname, l.course, l.expires_at
+		FROM latest l
+		INNER JOIN users u ON u.id = l.trainee_id AND u.deleted_at IS NULL
+		WHERE l.expires_at > NOW() AND l.expires_at <= NOW() + make_interval(secs => $2)
+		AND NOT EXISTS (
+			SELECT 1 FROM notifications_sent n
+			WHERE n.kind = $1 AND n.user_id = u.id AND n.reference_id = l.id
+		)
+		ORDER BY l.expires_at ASC, u.id ASC`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, NotificationQualificationExpiring, window.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reminders := []*Reminder{}
+	for rows.Next() {
+		reminder := Reminder{Kind: NotificationQualificationExpiring}
+		err := rows.Scan(
+			&reminder.UserID,
+			&reminder.ReferenceID,
+			&reminder.Email,
+			&reminder.FName,
+			&reminder.Course,
+			&reminder.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		reminders = append(reminders, &reminder)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// Record a reminder as sent and queue its email in the outbox, together, so
+// that a reminder is never recorded without its email or queued twice. ok is
+// false if it has already been claimed, by an earlier run or another
+// instance, and nothing was queued.
+func (m NotificationModel) Claim(reminder *Reminder, email *Email) (ok bool, err error) {
+	query := `
+		INSERT INTO notifications_sent (kind, user_id, reference_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (kind, user_id, reference_id) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := m.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, query, reminder.Kind, reminder.UserID, reminder.ReferenceID)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	err = enqueueEmail(ctx, tx, email)
+	if err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
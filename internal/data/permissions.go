@@ -4,7 +4,9 @@ package data
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/lib/pq"
@@ -23,15 +25,22 @@ type PermissionModel struct {
 	DB *sql.DB
 }
 
-// What are all the permissions associated with the user
+// What are all the permissions associated with the user. This is every
+// permission granted to them directly plus those granted by their roles.
 func (p PermissionModel) GetAllForUser(userID int64) (Permissions, error) {
 	query := `
             SELECT permissions.code
             FROM permissions 
         	INNER JOIN users_permissions ON 
             users_permissions.permission_id = permissions.id
-			INNER JOIN users ON users_permissions.user_id = users.id
-            WHERE users.id = $1
+            WHERE users_permissions.user_id = $1
+            UNION
+            SELECT permissions.code
+            FROM permissions
+            INNER JOIN role_permissions ON
+            role_permissions.permission_id = permissions.id
+            INNER JOIN users_role ON users_role.role_id = role_permissions.role_id
+            WHERE users_role.user_id = $1
           `
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -63,29 +72,232 @@ func (p PermissionModel) GetAllForUser(userID int64) (Permissions, error) {
 
 }
 
-// Add permissions for the user.
-func (p PermissionModel) AddForUser(userID int64, codes ...string) error {
+// Add permissions for the user, limited to the given scope. Codes the user
+// already has are moved to the new scope.
+func (p PermissionModel) AddForUser(userID int64, scope GrantScope, codes ...string) error {
 	query := `
-        INSERT INTO users_permissions
-        SELECT $1, permissions.id FROM permissions 
+        INSERT INTO users_permissions (user_id, permission_id, region_id, formation_id)
+        SELECT $1, permissions.id, NULLIF($3, 0), NULLIF($4, 0) FROM permissions 
         WHERE permissions.code = ANY($2)
+        ON CONFLICT (user_id, permission_id)
+        DO UPDATE SET region_id = EXCLUDED.region_id, formation_id = EXCLUDED.formation_id
        `
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
+	unknown, err := p.unknownCodes(ctx, codes)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrPermissionNotFound, strings.Join(unknown, ", "))
+	}
+
 	// slices need to be converted to arrays to work in PostgreSQL
-	_, err := p.DB.ExecContext(ctx, query, userID, pq.Array(codes))
+	_, err = p.DB.ExecContext(ctx, query, userID, pq.Array(codes), scope.RegionID, scope.FormationID)
+	if isForeignKeyViolation(err) {
+		return ErrRecordNotFound
+	}
 
 	return err
 }
 
+// A permission granted directly to a user and where it applies
+type PermissionGrant struct {
+	Code string `json:"code"`
+	GrantScope
+}
+
+// The permissions granted to the user directly, leaving out those that
+// come from their roles
+func (p PermissionModel) GetDirectForUser(userID int64) ([]*PermissionGrant, error) {
+	query := `
+		SELECT permissions.code, COALESCE(users_permissions.region_id, 0), COALESCE(users_permissions.formation_id, 0)
+		FROM permissions
+		INNER JOIN users_permissions ON users_permissions.permission_id = permissions.id
+		WHERE users_permissions.user_id = $1
+		ORDER BY permissions.code`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := p.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	grants := []*PermissionGrant{}
+	for rows.Next() {
+		var grant PermissionGrant
+		err := rows.Scan(&grant.Code, &grant.RegionID, &grant.FormationID)
+		if err != nil {
+			return nil, err
+		}
+		grants = append(grants, &grant)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return grants, nil
+}
+
+// Work out how far the user's grants of a permission reach, whether they
+// hold it directly or through a role. The widest grant wins: any national
+// grant makes the scope national, otherwise the formations of every region
+// and formation grant are combined. Returns nil when the user doesn't hold
+// the permission at all.
+func (p PermissionModel) ScopeForUser(userID int64, code string) (*AccessScope, error) {
+	query := `
+		WITH grants AS (
+			SELECT up.region_id, up.formation_id
+			FROM users_permissions up
+			INNER JOIN permissions perm ON perm.id = up.permission_id
+			WHERE up.user_id = $1 AND perm.code = $2
+			UNION ALL
+			SELECT ur.region_id, ur.formation_id
+			FROM users_role ur
+			INNER JOIN role_permissions rp ON rp.role_id = ur.role_id
+			INNER JOIN permissions perm ON perm.id = rp.permission_id
+			WHERE ur.user_id = $1 AND perm.code = $2
+		)
+		SELECT COUNT(*) > 0,
+		       COALESCE(bool_or(g.region_id IS NULL AND g.formation_id IS NULL), false),
+		       COALESCE(array_agg(DISTINCT f.id) FILTER (WHERE f.id IS NOT NULL), '{}')
+		FROM grants g
+		LEFT JOIN formation f ON f.id = g.formation_id OR f.region_id = g.region_id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var held bool
+	var scope AccessScope
+	err := p.DB.QueryRowContext(ctx, query, userID, code).Scan(&held, &scope.National, pq.Array(&scope.FormationIDs))
+	if err != nil {
+		return nil, err
+	}
+	if !held {
+		return nil, nil
+	}
+	if scope.National {
+		scope.FormationIDs = nil
+	}
+
+	return &scope, nil
+}
+
+// Does the access scope reach everywhere the grant would apply. Only a
+// national scope covers a national grant; a region is covered when every
+// one of its formations is.
+func (p PermissionModel) ScopeCovers(within *AccessScope, grant GrantScope) (bool, error) {
+	if within == nil {
+		return false, nil
+	}
+	if within.National {
+		return true, nil
+	}
+	if grant.RegionID == 0 && grant.FormationID == 0 {
+		return false, nil
+	}
+
+	query := `
+		SELECT NOT EXISTS (
+			SELECT 1 FROM formation f
+			WHERE (f.region_id = $1 OR f.id = $2)
+			AND NOT f.id = ANY($3)
+		)`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var covered bool
+	err := p.DB.QueryRowContext(ctx, query, grant.RegionID, grant.FormationID, pq.Array(within.FormationIDs)).Scan(&covered)
+	return covered, err
+}
+
+// Take a directly granted permission away from the user
+func (p PermissionModel) DeleteForUser(userID int64, code string) error {
+	query := `
+		DELETE FROM users_permissions
+		USING permissions
+		WHERE users_permissions.permission_id = permissions.id
+		AND users_permissions.user_id = $1 AND permissions.code = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := p.DB.ExecContext(ctx, query, userID, code)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// A permission code that can be granted
+type Permission struct {
+	ID   int64  `json:"id"`
+	Code string `json:"code"`
+}
+
+// Every permission code in the system
+func (p PermissionModel) GetAll() ([]*Permission, error) {
+	query := `
+		SELECT id, code
+		FROM permissions
+		ORDER BY code`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := p.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []*Permission{}
+	for rows.Next() {
+		var permission Permission
+		err := rows.Scan(&permission.ID, &permission.Code)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, &permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
 
 func (p PermissionModel) HasForUser(userID int64, permissionCode string) (bool, error) {
     query := `
         SELECT COUNT(*)
-        FROM users_permissions up
-        INNER JOIN permissions perm ON up.permission_id = perm.id
-        WHERE up.user_id = $1 AND perm.code = $2;
+        FROM permissions perm
+        WHERE perm.code = $2
+        AND (
+            EXISTS (SELECT 1 FROM users_permissions up WHERE up.permission_id = perm.id AND up.user_id = $1)
+            OR EXISTS (
+                SELECT 1 FROM role_permissions rp
+                INNER JOIN users_role ur ON ur.role_id = rp.role_id
+                WHERE rp.permission_id = perm.id AND ur.user_id = $1
+            )
+        );
     `
 
     var count int
@@ -95,4 +307,112 @@ func (p PermissionModel) HasForUser(userID int64, permissionCode string) (bool,
     }
 
     return count > 0, nil
-}
\ No newline at end of file
+}
+
+// ------------------- ROLE PERMISSIONS -------------------
+
+// Find which of the codes are not real permission codes
+func (p PermissionModel) unknownCodes(ctx context.Context, codes []string) ([]string, error) {
+	query := `
+		SELECT COALESCE(array_agg(c.code), '{}')
+		FROM UNNEST($1::text[]) AS c(code)
+		WHERE c.code NOT IN (SELECT code FROM permissions)`
+
+	var unknown []string
+	err := p.DB.QueryRowContext(ctx, query, pq.Array(codes)).Scan(pq.Array(&unknown))
+	return unknown, err
+}
+
+// The permissions granted to everyone holding a role
+func (p PermissionModel) GetAllForRole(roleID int64) (Permissions, error) {
+	query := `
+		SELECT permissions.code
+		FROM permissions
+		INNER JOIN role_permissions ON role_permissions.permission_id = permissions.id
+		WHERE role_permissions.role_id = $1
+		ORDER BY permissions.code`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return p.queryCodes(ctx, query, roleID)
+}
+
+// Run a query that returns a single column of permission codes
+func (p PermissionModel) queryCodes(ctx context.Context, query string, args ...any) (Permissions, error) {
+	rows, err := p.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := Permissions{}
+	for rows.Next() {
+		var permission string
+		err := rows.Scan(&permission)
+		if err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// Grant permissions to a role. Codes the role already has are skipped.
+func (p PermissionModel) AddForRole(roleID int64, codes ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	unknown, err := p.unknownCodes(ctx, codes)
+	if err != nil {
+		return err
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("%w: %s", ErrPermissionNotFound, strings.Join(unknown, ", "))
+	}
+
+	query := `
+		INSERT INTO role_permissions (role_id, permission_id)
+		SELECT $1, permissions.id FROM permissions
+		WHERE permissions.code = ANY($2)
+		ON CONFLICT DO NOTHING`
+
+	_, err = p.DB.ExecContext(ctx, query, roleID, pq.Array(codes))
+	if isForeignKeyViolation(err) {
+		return ErrRecordNotFound
+	}
+	return err
+}
+
+// Take a permission away from a role
+func (p PermissionModel) DeleteForRole(roleID int64, code string) error {
+	query := `
+		DELETE FROM role_permissions
+		USING permissions
+		WHERE role_permissions.permission_id = permissions.id
+		AND role_permissions.role_id = $1 AND permissions.code = $2`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := p.DB.ExecContext(ctx, query, roleID, code)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
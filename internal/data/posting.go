@@ -0,0 +1,177 @@
+// Filename: internal/data/posting.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+type Posting struct {
+	ID        int64     `json:"id"`
+	Posting   string    `json:"posting"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// Performs the validation checks
+func ValidatePosting(v *validator.Validator, posting *Posting) {
+	v.Check(posting.Posting != "", "posting", "must be provided")
+	v.Check(len(posting.Posting) <= 100, "posting", "must not be more than 100 bytes long")
+}
+
+type PostingModel struct {
+	DB *sql.DB
+}
+
+// Insert a new posting into the database
+func (p PostingModel) Insert(posting *Posting) error {
+	query := `
+		INSERT INTO posting (posting)
+		VALUES ($1)
+		RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return p.DB.QueryRowContext(ctx, query, posting.Posting).Scan(&posting.ID, &posting.CreatedAt)
+}
+
+// Get a specific posting from the database
+func (p PostingModel) Get(id int64) (*Posting, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, posting, created_at
+		FROM posting
+		WHERE id = $1`
+
+	var posting Posting
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := p.DB.QueryRowContext(ctx, query, id).Scan(
+		&posting.ID,
+		&posting.Posting,
+		&posting.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &posting, nil
+}
+
+// Update a specific posting in the database
+func (p PostingModel) Update(posting *Posting) error {
+	query := `
+		UPDATE posting
+		SET posting = $1
+		WHERE id = $2
+		RETURNING created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := p.DB.QueryRowContext(ctx, query, posting.Posting, posting.ID).Scan(&posting.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete a specific posting from the database. Officers holding the posting
+// have their posting_id set to NULL and the course requirements for the
+// posting are removed along with it.
+func (p PostingModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM posting
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := p.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrRecordInUse
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Get all postings from the database
+func (p PostingModel) GetAll(posting string, filters Filters) ([]*Posting, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), id, posting, created_at
+		FROM posting
+		WHERE (to_tsvector('simple', posting) @@ plainto_tsquery('simple', $1) OR $1 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := p.DB.QueryContext(ctx, query, posting, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	postings := []*Posting{}
+
+	for rows.Next() {
+		var posting Posting
+		err := rows.Scan(
+			&totalRecords,
+			&posting.ID,
+			&posting.Posting,
+			&posting.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		postings = append(postings, &posting)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return postings, metadata, nil
+}
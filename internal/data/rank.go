@@ -0,0 +1,184 @@
+// Filename: internal/data/rank.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Seniority orders ranks from junior to senior and is what minimum rank
+// prerequisites are compared on
+type Rank struct {
+	ID        int64     `json:"id"`
+	Title     string    `json:"title"`
+	Seniority int       `json:"seniority"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// Performs the validation checks
+func ValidateRank(v *validator.Validator, rank *Rank) {
+	v.Check(rank.Title != "", "title", "must be provided")
+	v.Check(len(rank.Title) <= 100, "title", "must not be more than 100 bytes long")
+	v.Check(rank.Seniority > 0, "seniority", "must be greater than zero")
+	v.Check(rank.Seniority <= 1000, "seniority", "must not be more than 1000")
+}
+
+type RankModel struct {
+	DB *sql.DB
+}
+
+// Insert a new rank into the database
+func (r RankModel) Insert(rank *Rank) error {
+	query := `
+		INSERT INTO rank (title, seniority)
+		VALUES ($1, $2)
+		RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return r.DB.QueryRowContext(ctx, query, rank.Title, rank.Seniority).Scan(&rank.ID, &rank.CreatedAt)
+}
+
+// Get a specific rank from the database
+func (r RankModel) Get(id int64) (*Rank, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, title, seniority, created_at
+		FROM rank
+		WHERE id = $1`
+
+	var rank Rank
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.DB.QueryRowContext(ctx, query, id).Scan(
+		&rank.ID,
+		&rank.Title,
+		&rank.Seniority,
+		&rank.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &rank, nil
+}
+
+// Update a specific rank in the database
+func (r RankModel) Update(rank *Rank) error {
+	query := `
+		UPDATE rank
+		SET title = $1, seniority = $2
+		WHERE id = $3
+		RETURNING created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.DB.QueryRowContext(ctx, query, rank.Title, rank.Seniority, rank.ID).Scan(&rank.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete a specific rank from the database. Officers holding the rank have
+// their rank_id set to NULL and the course requirements for the rank are
+// removed along with it.
+func (r RankModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM rank
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrRecordInUse
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Get all ranks from the database
+func (r RankModel) GetAll(title string, filters Filters) ([]*Rank, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), id, title, seniority, created_at
+		FROM rank
+		WHERE (to_tsvector('simple', title) @@ plainto_tsquery('simple', $1) OR $1 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, query, title, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	ranks := []*Rank{}
+
+	for rows.Next() {
+		var rank Rank
+		err := rows.Scan(
+			&totalRecords,
+			&rank.ID,
+			&rank.Title,
+			&rank.Seniority,
+			&rank.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		ranks = append(ranks, &rank)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return ranks, metadata, nil
+}
@@ -0,0 +1,177 @@
+// Filename: internal/data/region.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+type Region struct {
+	ID        int64     `json:"id"`
+	Region    string    `json:"region"`
+	CreatedAt time.Time `json:"-"`
+}
+
+// Performs the validation checks
+func ValidateRegion(v *validator.Validator, region *Region) {
+	v.Check(region.Region != "", "region", "must be provided")
+	v.Check(len(region.Region) <= 100, "region", "must not be more than 100 bytes long")
+}
+
+type RegionModel struct {
+	DB *sql.DB
+}
+
+// Insert a new region into the database
+func (r RegionModel) Insert(region *Region) error {
+	query := `
+		INSERT INTO region (region)
+		VALUES ($1)
+		RETURNING id, created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return r.DB.QueryRowContext(ctx, query, region.Region).Scan(&region.ID, &region.CreatedAt)
+}
+
+// Get a specific region from the database
+func (r RegionModel) Get(id int64) (*Region, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, region, created_at
+		FROM region
+		WHERE id = $1`
+
+	var region Region
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.DB.QueryRowContext(ctx, query, id).Scan(
+		&region.ID,
+		&region.Region,
+		&region.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &region, nil
+}
+
+// Update a specific region in the database
+func (r RegionModel) Update(region *Region) error {
+	query := `
+		UPDATE region
+		SET region = $1
+		WHERE id = $2
+		RETURNING created_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := r.DB.QueryRowContext(ctx, query, region.Region, region.ID).Scan(&region.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete a specific region from the database. Deleting a region cascades to
+// its formations, so the delete is refused while any of those formations
+// still have officers attached to them.
+func (r RegionModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM region
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := r.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrRecordInUse
+		}
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Get all regions from the database
+func (r RegionModel) GetAll(region string, filters Filters) ([]*Region, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), id, region, created_at
+		FROM region
+		WHERE (to_tsvector('simple', region) @@ plainto_tsquery('simple', $1) OR $1 = '')
+		ORDER BY %s %s, id ASC
+		LIMIT $2 OFFSET $3`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := r.DB.QueryContext(ctx, query, region, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	regions := []*Region{}
+
+	for rows.Next() {
+		var region Region
+		err := rows.Scan(
+			&totalRecords,
+			&region.ID,
+			&region.Region,
+			&region.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		regions = append(regions, &region)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return regions, metadata, nil
+}
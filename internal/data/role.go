@@ -227,15 +227,17 @@ func (r Roles) Include(role string) bool {
 
 // }
 
-func (r RoleModel) AddForUserRole(userID int64, roleIDs ...int) error {
+// Assign roles to a user. The permissions that come with the roles only
+// reach as far as the scope given here.
+func (r RoleModel) AddForUserRole(userID int64, scope GrantScope, roleIDs ...int) error {
     query := `
-        INSERT INTO users_role (user_id, role_id)
-        VALUES ($1, unnest($2::int[]))
+        INSERT INTO users_role (user_id, role_id, region_id, formation_id)
+        SELECT $1, unnest($2::int[]), NULLIF($3, 0), NULLIF($4, 0)
     `
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    _, err := r.DB.ExecContext(ctx, query, userID, pq.Array(roleIDs))
+    _, err := r.DB.ExecContext(ctx, query, userID, pq.Array(roleIDs), scope.RegionID, scope.FormationID)
     return err
 }
 
@@ -297,6 +299,39 @@ func (r RoleModel) UpdateForUserRole(userID, oldRoleID, newRoleID int) error {
     return nil
 }
 
+// Where each of the user's assignments of a role applies
+func (r RoleModel) GetScopesForUserRole(userID, roleID int) ([]GrantScope, error) {
+    query := `
+        SELECT COALESCE(region_id, 0), COALESCE(formation_id, 0)
+        FROM users_role
+        WHERE user_id = $1 AND role_id = $2
+    `
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    rows, err := r.DB.QueryContext(ctx, query, userID, roleID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var scopes []GrantScope
+    for rows.Next() {
+        var scope GrantScope
+        err := rows.Scan(&scope.RegionID, &scope.FormationID)
+        if err != nil {
+            return nil, err
+        }
+        scopes = append(scopes, scope)
+    }
+
+    if err = rows.Err(); err != nil {
+        return nil, err
+    }
+
+    return scopes, nil
+}
+
 // DeleteForUser removes a specific role from a user
 func (r RoleModel) DeleteForUserRole(userID, roleID int) error {
     query := `
@@ -0,0 +1,37 @@
+// Filename: internal/data/scope.go
+package data
+
+import (
+	"slices"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+)
+
+// Where a permission grant or role assignment applies. Leaving both IDs at
+// zero makes the grant national.
+type GrantScope struct {
+	RegionID    int64 `json:"region_id,omitempty"`
+	FormationID int64 `json:"formation_id,omitempty"`
+}
+
+func ValidateGrantScope(v *validator.Validator, scope GrantScope) {
+	v.Check(scope.RegionID >= 0, "region_id", "must be a positive integer")
+	v.Check(scope.FormationID >= 0, "formation_id", "must be a positive integer")
+	v.Check(scope.RegionID == 0 || scope.FormationID == 0, "formation_id", "cannot be set together with region_id")
+}
+
+// The records a user can reach with one of their permissions. A national
+// scope reaches everything; otherwise only records belonging to one of
+// the listed formations are visible.
+type AccessScope struct {
+	National     bool    `json:"national"`
+	FormationIDs []int64 `json:"formation_ids,omitempty"`
+}
+
+// For work done on the caller's own behalf or by the system itself
+var NationalScope = &AccessScope{National: true}
+
+// Does the scope reach records belonging to the formation
+func (s *AccessScope) Includes(formationID int64) bool {
+	return s.National || slices.Contains(s.FormationIDs, formationID)
+}
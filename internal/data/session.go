@@ -8,22 +8,44 @@ import (
     "time"
 
     "github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+    "github.com/lib/pq"
 )
 
 type Session struct {
-    ID            int64     `json:"id"`
-    CourseID      int64     `json:"course_id"`
-    FormationID   int64     `json:"formation_id"`
-    FacilitatorID int64     `json:"facilitator_id"`
-    CreatedAt     time.Time `json:"created_at"`
+    ID            int64      `json:"id"`
+    CourseID      int64      `json:"course_id"`
+    FormationID   int64      `json:"formation_id"`
+    FacilitatorID int64      `json:"facilitator_id"`
+    StartsAt      time.Time  `json:"starts_at"`
+    EndsAt        time.Time  `json:"ends_at"`
+    Venue         string     `json:"venue"`
+    Capacity      int        `json:"capacity"`
+    Status        string     `json:"status"`
+    CreatedAt     time.Time  `json:"created_at"`
+    DeletedAt     *time.Time `json:"deleted_at,omitempty"`
 }
 
+// Where a session is in its lifecycle
+const (
+    SessionStatusPlanned   = "planned"
+    SessionStatusOngoing   = "ongoing"
+    SessionStatusCompleted = "completed"
+    SessionStatusCancelled = "cancelled"
+)
 
+var SessionStatuses = []string{SessionStatusPlanned, SessionStatusOngoing, SessionStatusCompleted, SessionStatusCancelled}
 
 func ValidateSession(v *validator.Validator, session *Session) {
     v.Check(session.CourseID > 0, "course_id", "must be provided")
     v.Check(session.FormationID > 0, "formation_id", "must be provided")
     v.Check(session.FacilitatorID > 0, "facilitator_id", "must be provided")
+    v.Check(!session.StartsAt.IsZero(), "starts_at", "must be provided")
+    v.Check(!session.EndsAt.IsZero(), "ends_at", "must be provided")
+    v.Check(!session.EndsAt.Before(session.StartsAt), "ends_at", "must not be before starts_at")
+    v.Check(len(session.Venue) <= 200, "venue", "must not be more than 200 bytes long")
+    // A capacity of 0 means there is no seat limit
+    v.Check(session.Capacity >= 0, "capacity", "must not be negative")
+    v.Check(validator.PermittedValue(session.Status, SessionStatuses...), "status", "must be one of planned, ongoing, completed or cancelled")
 }
 
 
@@ -35,38 +57,48 @@ type SessionModel struct {
 // Insert a new row in the role table
 func (s SessionModel) Insert(session *Session) error {
     query := `
-        INSERT INTO session (course_id, formation_id, facilitator_id)
-        VALUES ($1, $2, $3)
+        INSERT INTO session (course_id, formation_id, facilitator_id, starts_at, ends_at, venue, capacity, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
         RETURNING id, created_at
     `
-    args := []any{session.CourseID, session.FormationID, session.FacilitatorID}
+    args := []any{session.CourseID, session.FormationID, session.FacilitatorID, session.StartsAt, session.EndsAt, session.Venue, session.Capacity, session.Status}
 
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
     return s.DB.QueryRowContext(ctx, query, args...).Scan(&session.ID, &session.CreatedAt)
 }
-func (s SessionModel) Get(id int64) (*Session, error) {
+// Get a session. Sessions outside the scope are reported as not found, as
+// are deleted ones unless includeDeleted is set.
+func (s SessionModel) Get(id int64, scope *AccessScope, includeDeleted bool) (*Session, error) {
     if id < 1 {
         return nil, ErrRecordNotFound
     }
 
     query := `
-        SELECT id, course_id, formation_id, facilitator_id, created_at
+        SELECT id, course_id, formation_id, facilitator_id, starts_at, ends_at, venue, capacity, status, created_at, deleted_at
         FROM session
         WHERE id = $1
+        AND ($2 OR formation_id = ANY($3))
+        AND ($4 OR deleted_at IS NULL)
     `
 
     var session Session
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    err := s.DB.QueryRowContext(ctx, query, id).Scan(
+    err := s.DB.QueryRowContext(ctx, query, id, scope.National, pq.Array(scope.FormationIDs), includeDeleted).Scan(
         &session.ID,
         &session.CourseID,
         &session.FormationID,
         &session.FacilitatorID,
+        &session.StartsAt,
+        &session.EndsAt,
+        &session.Venue,
+        &session.Capacity,
+        &session.Status,
         &session.CreatedAt,
+        &session.DeletedAt,
     )
 
     if err != nil {
@@ -80,32 +112,102 @@ func (s SessionModel) Get(id int64) (*Session, error) {
 
     return &session, nil
 }
+// Save changes to a session. Credit and completion depend on the session's
+// dates, so they are worked out again for its trainees in the same
+// transaction.
 func (s SessionModel) Update(session *Session) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    tx, err := s.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    query := `
+        UPDATE session
+        SET course_id = $1, formation_id = $2, facilitator_id = $3,
+            starts_at = $4, ends_at = $5, venue = $6, capacity = $7, status = $8
+        WHERE id = $9
+    `
+    args := []any{
+        session.CourseID,
+        session.FormationID,
+        session.FacilitatorID,
+        session.StartsAt,
+        session.EndsAt,
+        session.Venue,
+        session.Capacity,
+        session.Status,
+        session.ID,
+    }
+
+    _, err = tx.ExecContext(ctx, query, args...)
+    if err != nil {
+        return err
+    }
+
+    err = recalculateSessionCredit(ctx, tx, session.ID)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+// Mark a session within the scope as deleted. Its enrolments and attendance
+// are kept and it can be restored until it is purged.
+func (s SessionModel) Delete(id int64, scope *AccessScope) error {
+    if id < 1 {
+        return ErrRecordNotFound
+    }
+
     query := `
         UPDATE session
-        SET course_id = $1, formation_id = $2, facilitator_id = $3
-        WHERE id = $4
+        SET deleted_at = NOW()
+        WHERE id = $1
+        AND ($2 OR formation_id = ANY($3))
+        AND deleted_at IS NULL
     `
 
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    _, err := s.DB.ExecContext(ctx, query, session.CourseID, session.FormationID, session.FacilitatorID, session.ID)
-    return err
+    result, err := s.DB.ExecContext(ctx, query, id, scope.National, pq.Array(scope.FormationIDs))
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return ErrRecordNotFound
+    }
+
+    return nil
 }
-func (s SessionModel) Delete(id int64) error {
+
+// Bring back a deleted session within the scope
+func (s SessionModel) Restore(id int64, scope *AccessScope) error {
     if id < 1 {
         return ErrRecordNotFound
     }
 
     query := `
-	DELETE FROM session WHERE id = $1
-	`
+        UPDATE session
+        SET deleted_at = NULL
+        WHERE id = $1
+        AND ($2 OR formation_id = ANY($3))
+        AND deleted_at IS NOT NULL
+    `
 
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    result, err := s.DB.ExecContext(ctx, query, id)
+    result, err := s.DB.ExecContext(ctx, query, id, scope.National, pq.Array(scope.FormationIDs))
     if err != nil {
         return err
     }
@@ -121,18 +223,60 @@ func (s SessionModel) Delete(id int64) error {
 
     return nil
 }
-func (s SessionModel) GetAll(filters Filters) ([]*Session, Metadata, error) {
+
+// Permanently remove sessions deleted before the cutoff, along with their
+// enrolments and attendance. Returns how many were removed.
+func (s SessionModel) Purge(deletedBefore time.Time) (int64, error) {
+    query := `
+        DELETE FROM session
+        WHERE deleted_at < $1
+    `
+
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    result, err := s.DB.ExecContext(ctx, query, deletedBefore)
+    if err != nil {
+        return 0, err
+    }
+
+    return result.RowsAffected()
+}
+
+// List sessions, optionally only those for one formation (0 means any),
+// with a given status, or starting on or after from and before to.
+// A zero from or to leaves that end of the range open. Only sessions
+// within the scope are listed, and deleted ones only with includeDeleted.
+func (s SessionModel) GetAll(formationID int64, status string, from, to time.Time, includeDeleted bool, filters Filters, scope *AccessScope) ([]*Session, Metadata, error) {
     query := fmt.Sprintf(`
-        SELECT COUNT(*) OVER(), id, course_id, formation_id, facilitator_id, created_at
+        SELECT COUNT(*) OVER(), id, course_id, formation_id, facilitator_id, starts_at, ends_at, venue, capacity, status, created_at, deleted_at
         FROM session
+        WHERE ($1 = 0 OR formation_id = $1)
+        AND ($2 = '' OR status = $2)
+        AND ($3::timestamptz IS NULL OR starts_at >= $3)
+        AND ($4::timestamptz IS NULL OR starts_at < $4)
+        AND ($7 OR formation_id = ANY($8))
+        AND ($9 OR deleted_at IS NULL)
         ORDER BY %s %s, id ASC
-        LIMIT $1 OFFSET $2
+        LIMIT $5 OFFSET $6
     `, filters.sortColumn(), filters.sortDirection())
 
+    args := []any{
+        formationID,
+        status,
+        sql.NullTime{Time: from, Valid: !from.IsZero()},
+        sql.NullTime{Time: to, Valid: !to.IsZero()},
+        filters.limit(),
+        filters.offset(),
+        scope.National,
+        pq.Array(scope.FormationIDs),
+        includeDeleted,
+    }
+
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    rows, err := s.DB.QueryContext(ctx, query, filters.limit(), filters.offset())
+    rows, err := s.DB.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, Metadata{}, err
     }
@@ -149,7 +293,13 @@ func (s SessionModel) GetAll(filters Filters) ([]*Session, Metadata, error) {
             &session.CourseID,
             &session.FormationID,
             &session.FacilitatorID,
+            &session.StartsAt,
+            &session.EndsAt,
+            &session.Venue,
+            &session.Capacity,
+            &session.Status,
             &session.CreatedAt,
+            &session.DeletedAt,
         )
         if err != nil {
             return nil, Metadata{}, err
@@ -0,0 +1,439 @@
+// Filename: internal/data/survey.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+	"github.com/lib/pq"
+)
+
+// The kinds of question a survey can ask
+const (
+	QuestionLikert = "likert"
+	QuestionChoice = "choice"
+	QuestionText   = "text"
+)
+
+var QuestionKinds = []string{QuestionLikert, QuestionChoice, QuestionText}
+
+// Likert questions are answered with a score from 1 to LikertMax
+const LikertMax = 5
+
+type SurveyQuestion struct {
+	ID       int64    `json:"id"`
+	Position int      `json:"position"`
+	Kind     string   `json:"kind"`
+	Prompt   string   `json:"prompt"`
+	Options  []string `json:"options,omitempty"`
+	Required bool     `json:"required"`
+}
+
+// The end-of-course evaluation for a course
+type Survey struct {
+	ID        int64             `json:"id"`
+	CourseID  int64             `json:"course_id"`
+	Title     string            `json:"title"`
+	Questions []*SurveyQuestion `json:"questions"`
+	CreatedAt time.Time         `json:"created_at"`
+}
+
+// Performs the checks on a survey and its questions
+func ValidateSurvey(v *validator.Validator, survey *Survey) {
+	v.Check(survey.Title != "", "title", "must be provided")
+	v.Check(len(survey.Title) <= 200, "title", "must not be more than 200 bytes long")
+	v.Check(len(survey.Questions) > 0, "questions", "must contain at least one question")
+	v.Check(len(survey.Questions) <= 50, "questions", "must not contain more than 50 questions")
+
+	for _, question := range survey.Questions {
+		v.Check(validator.PermittedValue(question.Kind, QuestionKinds...), "questions", "every question kind must be one of likert, choice or text")
+		v.Check(question.Prompt != "", "questions", "every question must have a prompt")
+		v.Check(len(question.Prompt) <= 500, "questions", "prompts must not be more than 500 bytes long")
+
+		if question.Kind == QuestionChoice {
+			v.Check(len(question.Options) >= 2, "questions", "choice questions must have at least two options")
+			seen := make(map[string]bool, len(question.Options))
+			for _, option := range question.Options {
+				v.Check(option != "", "questions", "choice options must not be empty")
+				v.Check(!seen[option], "questions", "choice options must not repeat")
+				seen[option] = true
+			}
+		} else {
+			v.Check(len(question.Options) == 0, "questions", "only choice questions can have options")
+		}
+	}
+}
+
+type SurveyAnswer struct {
+	QuestionID int64  `json:"question_id"`
+	Value      string `json:"value"`
+}
+
+// One trainee's answers to a survey for a session. RespondentID is 0 when
+// the response was given anonymously. The id is never shown and no time is
+// kept, since either could be matched against when the trainee responded.
+type SurveyResponse struct {
+	ID           int64           `json:"-"`
+	SurveyID     int64           `json:"survey_id"`
+	SessionID    int64           `json:"session_id"`
+	RespondentID int64           `json:"respondent_id,omitempty"`
+	Answers      []*SurveyAnswer `json:"answers"`
+}
+
+// Check the answers against the survey's questions. Every required question
+// must be answered and each answer must suit its question.
+func ValidateSurveyResponse(v *validator.Validator, survey *Survey, response *SurveyResponse) {
+	answers := make(map[int64]string, len(response.Answers))
+	for _, answer := range response.Answers {
+		_, seen := answers[answer.QuestionID]
+		v.Check(!seen, "answers", "must not answer the same question more than once")
+		answers[answer.QuestionID] = answer.Value
+	}
+
+	questions := make(map[int64]bool, len(survey.Questions))
+	for _, question := range survey.Questions {
+		questions[question.ID] = true
+		key := "answers." + strconv.FormatInt(question.ID, 10)
+
+		value, ok := answers[question.ID]
+		if !ok || value == "" {
+			v.Check(!question.Required, key, "must be answered")
+			continue
+		}
+
+		switch question.Kind {
+		case QuestionLikert:
+			score, err := strconv.Atoi(value)
+			v.Check(err == nil && score >= 1 && score <= LikertMax, key, "must be a score from 1 to 5")
+		case QuestionChoice:
+			v.Check(validator.PermittedValue(value, question.Options...), key, "must be one of the question's options")
+		case QuestionText:
+			v.Check(len(value) <= 1000, key, "must not be more than 1000 bytes long")
+		}
+	}
+
+	for id := range answers {
+		v.Check(questions[id], "answers", "must only answer questions on this survey")
+	}
+}
+
+type SurveyModel struct {
+	DB *sql.DB
+}
+
+// Create or replace the survey for a course. The questions are replaced as
+// a whole, which is refused with ErrRecordInUse once anyone has responded
+// so that existing answers keep their meaning.
+func (s SurveyModel) Set(survey *Survey) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO survey (course_id, title)
+		VALUES ($1, $2)
+		ON CONFLICT (course_id)
+		DO UPDATE SET title = EXCLUDED.title
+		RETURNING id, created_at`
+
+	err = tx.QueryRowContext(ctx, query, survey.CourseID, survey.Title).Scan(&survey.ID, &survey.CreatedAt)
+	if err != nil {
+		if isForeignKeyViolation(err) {
+			return ErrCourseNotFound
+		}
+		return err
+	}
+
+	var answered bool
+	query = `SELECT EXISTS (SELECT 1 FROM survey_response WHERE survey_id = $1)`
+	err = tx.QueryRowContext(ctx, query, survey.ID).Scan(&answered)
+	if err != nil {
+		return err
+	}
+	if answered {
+		return ErrRecordInUse
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM survey_question WHERE survey_id = $1`, survey.ID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO survey_question (survey_id, position, kind, prompt, options, required)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id`
+
+	for i, question := range survey.Questions {
+		question.Position = i + 1
+		if question.Options == nil {
+			question.Options = []string{}
+		}
+		args := []any{survey.ID, question.Position, question.Kind, question.Prompt, pq.Array(question.Options), question.Required}
+		err = tx.QueryRowContext(ctx, query, args...).Scan(&question.ID)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Get the survey for a course along with its questions in order
+func (s SurveyModel) GetForCourse(courseID int64) (*Survey, error) {
+	if courseID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, course_id, title, created_at
+		FROM survey
+		WHERE course_id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var survey Survey
+	err := s.DB.QueryRowContext(ctx, query, courseID).Scan(&survey.ID, &survey.CourseID, &survey.Title, &survey.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	query = `
+		SELECT id, position, kind, prompt, options, required
+		FROM survey_question
+		WHERE survey_id = $1
+		ORDER BY position ASC`
+
+	rows, err := s.DB.QueryContext(ctx, query, survey.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	survey.Questions = []*SurveyQuestion{}
+	for rows.Next() {
+		var question SurveyQuestion
+		err := rows.Scan(&question.ID, &question.Position, &question.Kind, &question.Prompt, pq.Array(&question.Options), &question.Required)
+		if err != nil {
+			return nil, err
+		}
+		survey.Questions = append(survey.Questions, &question)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &survey, nil
+}
+
+// Save a trainee's answers for a session. The trainee must have a
+// user_session in the session and can only respond once. When anonymous
+// is set the response isn't linked to the trainee.
+func (s SurveyModel) InsertResponse(response *SurveyResponse, traineeID int64, anonymous bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var enrolled bool
+	query := `SELECT EXISTS (SELECT 1 FROM user_session WHERE session_id = $1 AND trainee_id = $2)`
+	err = tx.QueryRowContext(ctx, query, response.SessionID, traineeID).Scan(&enrolled)
+	if err != nil {
+		return err
+	}
+	if !enrolled {
+		return ErrNotEnrolled
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO survey_submission (session_id, user_id) VALUES ($1, $2)`, response.SessionID, traineeID)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicateResponse
+		}
+		return err
+	}
+
+	response.RespondentID = traineeID
+	if anonymous {
+		response.RespondentID = 0
+	}
+
+	query = `
+		INSERT INTO survey_response (survey_id, session_id, respondent_id)
+		VALUES ($1, $2, NULLIF($3, 0))
+		RETURNING id`
+
+	err = tx.QueryRowContext(ctx, query, response.SurveyID, response.SessionID, response.RespondentID).Scan(&response.ID)
+	if err != nil {
+		return err
+	}
+
+	query = `
+		INSERT INTO survey_answer (response_id, question_id, value)
+		VALUES ($1, $2, $3)`
+
+	for _, answer := range response.Answers {
+		// Optional questions that were skipped aren't stored
+		if answer.Value == "" {
+			continue
+		}
+		_, err = tx.ExecContext(ctx, query, response.ID, answer.QuestionID, answer.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// How one question was answered. Likert questions get an average and a
+// count per score, choice questions a count per option and text questions
+// the answers themselves.
+type QuestionResult struct {
+	QuestionID int64          `json:"question_id"`
+	Position   int            `json:"position"`
+	Kind       string         `json:"kind"`
+	Prompt     string         `json:"prompt"`
+	Answered   int            `json:"answered"`
+	Average    float64        `json:"average,omitempty"`
+	Counts     map[string]int `json:"counts,omitempty"`
+	Answers    []string       `json:"answers,omitempty"`
+}
+
+// The answers to a survey added up, for one session or, when SessionID is
+// 0, every session of the course
+type SurveyResults struct {
+	SurveyID  int64             `json:"survey_id"`
+	CourseID  int64             `json:"course_id"`
+	SessionID int64             `json:"session_id,omitempty"`
+	Responses int               `json:"responses"`
+	Questions []*QuestionResult `json:"questions"`
+}
+
+// Add up the answers to a survey, optionally only for one session (0 means
+// every session)
+func (s SurveyModel) GetResults(survey *Survey, sessionID int64) (*SurveyResults, error) {
+	results := &SurveyResults{
+		SurveyID:  survey.ID,
+		CourseID:  survey.CourseID,
+		SessionID: sessionID,
+		Questions: make([]*QuestionResult, 0, len(survey.Questions)),
+	}
+
+	byQuestion := make(map[int64]*QuestionResult, len(survey.Questions))
+	for _, question := range survey.Questions {
+		result := &QuestionResult{
+			QuestionID: question.ID,
+			Position:   question.Position,
+			Kind:       question.Kind,
+			Prompt:     question.Prompt,
+		}
+		switch question.Kind {
+		case QuestionLikert:
+			result.Counts = make(map[string]int, LikertMax)
+			for score := 1; score <= LikertMax; score++ {
+				result.Counts[strconv.Itoa(score)] = 0
+			}
+		case QuestionChoice:
+			result.Counts = make(map[string]int, len(question.Options))
+			for _, option := range question.Options {
+				result.Counts[option] = 0
+			}
+		case QuestionText:
+			result.Answers = []string{}
+		}
+		byQuestion[question.ID] = result
+		results.Questions = append(results.Questions, result)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT COUNT(*)
+		FROM survey_response
+		WHERE survey_id = $1
+		AND ($2 = 0 OR session_id = $2)`
+
+	err := s.DB.QueryRowContext(ctx, query, survey.ID, sessionID).Scan(&results.Responses)
+	if err != nil {
+		return nil, err
+	}
+
+	query = `
+		SELECT a.question_id, a.value, COUNT(*)
+		FROM survey_answer a
+		INNER JOIN survey_response r ON r.id = a.response_id
+		WHERE r.survey_id = $1
+		AND ($2 = 0 OR r.session_id = $2)
+		GROUP BY a.question_id, a.value`
+
+	rows, err := s.DB.QueryContext(ctx, query, survey.ID, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var questionID int64
+		var value string
+		var count int
+		err := rows.Scan(&questionID, &value, &count)
+		if err != nil {
+			return nil, err
+		}
+
+		result, ok := byQuestion[questionID]
+		if !ok {
+			continue
+		}
+		result.Answered += count
+
+		switch result.Kind {
+		case QuestionLikert:
+			score, _ := strconv.Atoi(value)
+			result.Counts[value] += count
+			result.Average += float64(score * count)
+		case QuestionChoice:
+			result.Counts[value] += count
+		case QuestionText:
+			for i := 0; i < count; i++ {
+				result.Answers = append(result.Answers, value)
+			}
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, result := range results.Questions {
+		if result.Kind == QuestionLikert && result.Answered > 0 {
+			result.Average /= float64(result.Answered)
+		}
+		sort.Strings(result.Answers)
+	}
+
+	return results, nil
+}
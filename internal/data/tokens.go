@@ -15,6 +15,7 @@ import (
 // Purpose of the token
 const ScopeActivation = "activation"
 const ScopeAuthentication = "authentication"
+const ScopePasswordReset = "password-reset"
 
 // Define our token
 type Token struct {
@@ -74,17 +75,68 @@ func (t TokenModel) New(userID int64, ttl time.Duration, scope string) (*Token,
 
 // Do the actual insert in to the database table
 func (t TokenModel) Insert(token *Token) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return insertToken(ctx, t.DB, token)
+}
+
+// Insert a token using db, which can be a transaction
+func insertToken(ctx context.Context, db execer, token *Token) error {
 	query := `
               INSERT INTO tokens (hash, user_id, expiry, scope) 
               VALUES ($1, $2, $3, $4)
 			  `
 	args := []any{token.Hash, token.UserID, token.Expiry, token.Scope}
 
+	_, err := db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// Replace any password reset token the user has with a new one and queue
+// the email carrying it, in one transaction. Only the most recent reset
+// token is valid.
+func (t TokenModel) NewPasswordReset(user *User, ttl time.Duration) error {
+	token, err := generateToken(user.ID, ttl, ScopePasswordReset)
+	if err != nil {
+		return err
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	_, err := t.DB.ExecContext(ctx, query, args...)
-	return err
+	tx, err := t.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+            DELETE FROM tokens 
+            WHERE scope = $1 AND user_id = $2
+			`
+	_, err = tx.ExecContext(ctx, query, ScopePasswordReset, user.ID)
+	if err != nil {
+		return err
+	}
+
+	err = insertToken(ctx, tx, token)
+	if err != nil {
+		return err
+	}
+
+	err = enqueueEmail(ctx, tx, &Email{
+		Recipient: user.Email,
+		Template:  "token_password_reset.tmpl",
+		Data: map[string]any{
+			"passwordResetToken": token.Plaintext,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
 }
 
 // Delete a token based on the type and the user
@@ -99,3 +151,31 @@ func (t TokenModel) DeleteAllForUser(scope string, userID int64) error {
 	_, err := t.DB.ExecContext(ctx, query, scope, userID)
 	return err
 }
+
+// Delete a single token, such as the one presented when signing out
+func (t TokenModel) DeleteByPlaintext(scope string, tokenPlaintext string) error {
+	query := `
+            DELETE FROM tokens 
+            WHERE hash = $1 AND scope = $2
+			`
+	hash := sha256.Sum256([]byte(tokenPlaintext))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := t.DB.ExecContext(ctx, query, hash[:], scope)
+	return err
+}
+
+// Delete every token the user holds whatever its scope
+func (t TokenModel) DeleteAllScopesForUser(userID int64) error {
+	query := `
+            DELETE FROM tokens 
+            WHERE user_id = $1
+			`
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	_, err := t.DB.ExecContext(ctx, query, userID)
+	return err
+}
@@ -0,0 +1,127 @@
+// Filename: internal/data/transcript.go
+package data
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// One session the officer completed. AttendancePercent is nil when no
+// roll has been taken for the session.
+type TranscriptEntry struct {
+	UserSessionID     int64     `json:"user_session_id"`
+	Course            string    `json:"course"`
+	Facilitator       string    `json:"facilitator"`
+	Hours             int64     `json:"hours"`
+	Grade             string    `json:"grade"`
+	AttendancePercent *float64  `json:"attendance_percent"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+type Transcript struct {
+	UserID           int64              `json:"user_id"`
+	Name             string             `json:"name"`
+	RegulationNumber string             `json:"regulation_number"`
+	Rank             string             `json:"rank"`
+	Formation        string             `json:"formation"`
+	Posting          string             `json:"posting"`
+	TotalHours       int64              `json:"total_hours"`
+	Entries          []*TranscriptEntry `json:"entries"`
+}
+
+type TranscriptModel struct {
+	DB *sql.DB
+}
+
+// Get an officer's details and every training session they completed.
+// Enrolments still in progress, or not passed, are left off.
+func (t TranscriptModel) Get(userID int64) (*Transcript, error) {
+	if userID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	transcript := &Transcript{
+		UserID:  userID,
+		Entries: []*TranscriptEntry{},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	query := `
+		SELECT u.fname || ' ' || u.lname, u.regulation_number,
+		       COALESCE(r.title, ''), f.formation, COALESCE(p.posting, '')
+		FROM users u
+		INNER JOIN formation f ON f.id = u.formation_id
+		LEFT JOIN rank r ON r.id = u.rank_id
+		LEFT JOIN posting p ON p.id = u.posting_id
+		WHERE u.id = $1`
+
+	err := t.DB.QueryRowContext(ctx, query, userID).Scan(
+		&transcript.Name,
+		&transcript.RegulationNumber,
+		&transcript.Rank,
+		&transcript.Formation,
+		&transcript.Posting,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	// Attendance is the share of roll-call days the officer was present
+	query = `
+		SELECT us.id, c.course, COALESCE(fac.fname || ' ' || fac.lname, ''),
+		       us.credithours_completed, COALESCE(us.grade, ''),
+		       (SELECT ROUND(100.0 * COUNT(*) FILTER (WHERE a.attendance) / NULLIF(COUNT(*), 0), 1)
+		        FROM attendance a
+		        WHERE a.user_session_id = us.id),
+		       us.created_at
+		FROM user_session us
+		INNER JOIN session s ON s.id = us.session_id
+		INNER JOIN course c ON c.id = s.course_id
+		LEFT JOIN users fac ON fac.id = s.facilitator_id
+		WHERE us.trainee_id = $1
+		AND us.completed
+		ORDER BY us.created_at ASC, us.id ASC`
+
+	rows, err := t.DB.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry TranscriptEntry
+		var attendance sql.NullFloat64
+		err := rows.Scan(
+			&entry.UserSessionID,
+			&entry.Course,
+			&entry.Facilitator,
+			&entry.Hours,
+			&entry.Grade,
+			&attendance,
+			&entry.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		if attendance.Valid {
+			entry.AttendancePercent = &attendance.Float64
+		}
+		transcript.TotalHours += entry.Hours
+		transcript.Entries = append(transcript.Entries, &entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return transcript, nil
+}
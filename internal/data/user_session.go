@@ -7,8 +7,8 @@ import (
     "fmt"
     "time"
 
-    // "github.com/lib/pq"
     "github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+    "github.com/lib/pq"
 )
 
 type UserSession struct {
@@ -18,10 +18,26 @@ type UserSession struct {
     CreditHoursCompleted int64     `json:"credithours_completed"`
     Grade                string    `json:"grade"`
     Feedback             string    `json:"feedback"`
-    Version              int       `json:"-"`
-    CreatedAt            time.Time `json:"created_at"`
+    Completed            bool      `json:"completed"`
+    // Set when the hours and completion were entered by hand rather than
+    // worked out from attendance
+    OverrideReason string     `json:"override_reason,omitempty"`
+    OverriddenBy   int64      `json:"overridden_by,omitempty"`
+    OverriddenAt   *time.Time `json:"overridden_at,omitempty"`
+    // When a completion of a course with a validity period lapses
+    ExpiresAt      *time.Time `json:"expires_at,omitempty"`
+    Version        int        `json:"-"`
+    CreatedAt      time.Time  `json:"created_at"`
 }
 
+// expires_at for the user session aliased us, NULL until it is completed
+const userSessionExpiresAt = `(
+                   SELECT ` + courseCompletionExpiry + `
+                   FROM session s
+                   INNER JOIN course c ON c.id = s.course_id
+                   WHERE s.id = us.session_id AND us.completed
+               )`
+
 // ------------------- VALIDATION -------------------
 
 func ValidateUserSession(v *validator.Validator, us *UserSession) {
@@ -31,6 +47,7 @@ func ValidateUserSession(v *validator.Validator, us *UserSession) {
     v.Check(len(us.Grade) <= 25, "grade", "must not be more than 25 bytes long")
     v.Check(us.Feedback != "", "feedback", "must be provided")
     v.Check(len(us.Feedback) <= 255, "feedback", "must not be more than 255 bytes long")
+    v.Check(len(us.OverrideReason) <= 255, "override_reason", "must not be more than 255 bytes long")
 }
 
 // ------------------- MODEL STRUCT -------------------
@@ -41,47 +58,118 @@ type UserSessionModel struct {
 
 // ------------------- ADD -------------------
 
+// Add a trainee to a session by hand. The session is locked the same way as
+// for self-enrolment so the seat limit holds; unlike self-enrolment this
+// works for sessions that are under way or finished, so that past training
+// can be recorded. A trainee on the waitlist is taken off it.
 func (m UserSessionModel) AddUserSession(us *UserSession) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    tx, err := m.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    capacity, _, err := lockSession(ctx, tx, us.SessionID)
+    if err != nil {
+        return err
+    }
+
+    var exists bool
+    var taken int
     query := `
-        INSERT INTO user_session (trainee_id, session_id, credithours_completed, grade, feedback)
-        VALUES ($1, $2, $3, $4, $5)
-        RETURNING id, created_at, version
+        SELECT EXISTS (SELECT 1 FROM user_session WHERE session_id = $1 AND trainee_id = $2),
+               (SELECT COUNT(*) FROM user_session WHERE session_id = $1)
     `
-    args := []any{us.TraineeID, us.SessionID, us.CreditHoursCompleted, us.Grade, us.Feedback}
+    err = tx.QueryRowContext(ctx, query, us.SessionID, us.TraineeID).Scan(&exists, &taken)
+    if err != nil {
+        return err
+    }
+    if exists {
+        return ErrAlreadyEnrolled
+    }
+    if capacity > 0 && taken >= capacity {
+        return ErrSessionFull
+    }
 
-    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
-    defer cancel()
+    query = `DELETE FROM session_waitlist WHERE session_id = $1 AND user_id = $2`
+    _, err = tx.ExecContext(ctx, query, us.SessionID, us.TraineeID)
+    if err != nil {
+        return err
+    }
+
+    query = `
+        INSERT INTO user_session AS us (trainee_id, session_id, credithours_completed, grade, feedback,
+                                        completed, override_reason, overridden_by, overridden_at)
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, 0), $9)
+        RETURNING id, created_at, version, ` + userSessionExpiresAt
+    args := []any{
+        us.TraineeID,
+        us.SessionID,
+        us.CreditHoursCompleted,
+        us.Grade,
+        us.Feedback,
+        us.Completed,
+        us.OverrideReason,
+        us.OverriddenBy,
+        us.OverriddenAt,
+    }
+
+    err = tx.QueryRowContext(ctx, query, args...).Scan(&us.ID, &us.CreatedAt, &us.Version, &us.ExpiresAt)
+    if err != nil {
+        return err
+    }
+
+    err = notifyCompletionChanges(ctx, tx, []int64{us.ID})
+    if err != nil {
+        return err
+    }
 
-    return m.DB.QueryRowContext(ctx, query, args...).Scan(&us.ID, &us.CreatedAt, &us.Version)
+    return tx.Commit()
 }
 
+// ------------------- SCOPE -------------------
+
+// A user session belongs to the formation running its session
+const userSessionScopeFilter = `($%d OR session_id IN (SELECT id FROM session WHERE formation_id = ANY($%d)))`
+
 // ------------------- GET BY ID -------------------
 
-func (m UserSessionModel) GetUserSession(id int64) (*UserSession, error) {
+// User sessions outside the scope are reported as not found
+func (m UserSessionModel) GetUserSession(id int64, scope *AccessScope) (*UserSession, error) {
     query := `
-        SELECT id, trainee_id, session_id, credithours_completed, grade, feedback, created_at, version
-        FROM user_session
+        SELECT id, trainee_id, session_id, credithours_completed, grade, feedback, completed,
+               COALESCE(override_reason, ''), COALESCE(overridden_by, 0), overridden_at, created_at, version,
+               ` + userSessionExpiresAt + `
+        FROM user_session us
         WHERE id = $1
-    `
+        AND ` + fmt.Sprintf(userSessionScopeFilter, 2, 3)
     var us UserSession
 
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    err := m.DB.QueryRowContext(ctx, query, id).Scan(
+    err := m.DB.QueryRowContext(ctx, query, id, scope.National, pq.Array(scope.FormationIDs)).Scan(
         &us.ID,
         &us.TraineeID,
         &us.SessionID,
         &us.CreditHoursCompleted,
         &us.Grade,
         &us.Feedback,
+        &us.Completed,
+        &us.OverrideReason,
+        &us.OverriddenBy,
+        &us.OverriddenAt,
         &us.CreatedAt,
         &us.Version,
+        &us.ExpiresAt,
     )
 
     if err != nil {
         if errors.Is(err, sql.ErrNoRows) {
-            return nil, fmt.Errorf("no user session found with id %d", id)
+            return nil, ErrRecordNotFound
         }
         return nil, err
     }
@@ -93,36 +181,70 @@ func (m UserSessionModel) GetUserSession(id int64) (*UserSession, error) {
 
 func (m UserSessionModel) UpdateUserSession(us *UserSession) error {
     query := `
-        UPDATE user_session
-        SET credithours_completed = $1, grade = $2, feedback = $3, version = version + 1
-        WHERE id = $4
-        RETURNING version
-    `
-    args := []any{us.CreditHoursCompleted, us.Grade, us.Feedback, us.ID}
+        UPDATE user_session us
+        SET credithours_completed = $1, grade = $2, feedback = $3, completed = $4,
+            override_reason = NULLIF($5, ''), overridden_by = NULLIF($6, 0), overridden_at = $7,
+            version = version + 1
+        WHERE id = $8 AND version = $9
+        RETURNING version, ` + userSessionExpiresAt
+    args := []any{
+        us.CreditHoursCompleted,
+        us.Grade,
+        us.Feedback,
+        us.Completed,
+        us.OverrideReason,
+        us.OverriddenBy,
+        us.OverriddenAt,
+        us.ID,
+        us.Version,
+    }
 
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    err := m.DB.QueryRowContext(ctx, query, args...).Scan(&us.Version)
+    tx, err := m.DB.BeginTx(ctx, nil)
     if err != nil {
-        if errors.Is(err, sql.ErrNoRows) {
-            return fmt.Errorf("no user session found to update with id %d", us.ID)
+        return err
+    }
+    defer tx.Rollback()
+
+    err = tx.QueryRowContext(ctx, query, args...).Scan(&us.Version, &us.ExpiresAt)
+    if err != nil {
+        if !errors.Is(err, sql.ErrNoRows) {
+            return err
         }
+
+        // Either the row is gone or someone else changed it first
+        var exists bool
+        query = `SELECT EXISTS (SELECT 1 FROM user_session WHERE id = $1)`
+        err = tx.QueryRowContext(ctx, query, us.ID).Scan(&exists)
+        switch {
+        case err != nil:
+            return err
+        case !exists:
+            return ErrRecordNotFound
+        default:
+            return ErrEditConflict
+        }
+    }
+
+    err = notifyCompletionChanges(ctx, tx, []int64{us.ID})
+    if err != nil {
         return err
     }
 
-    return nil
+    return tx.Commit()
 }
 
 // ------------------- DELETE -------------------
 
-func (m UserSessionModel) DeleteUserSession(id int64) error {
-    query := `DELETE FROM user_session WHERE id = $1`
+func (m UserSessionModel) DeleteUserSession(id int64, scope *AccessScope) error {
+    query := `DELETE FROM user_session WHERE id = $1 AND ` + fmt.Sprintf(userSessionScopeFilter, 2, 3)
 
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    result, err := m.DB.ExecContext(ctx, query, id)
+    result, err := m.DB.ExecContext(ctx, query, id, scope.National, pq.Array(scope.FormationIDs))
     if err != nil {
         return err
     }
@@ -133,7 +255,7 @@ func (m UserSessionModel) DeleteUserSession(id int64) error {
     }
 
     if rowsAffected == 0 {
-        return fmt.Errorf("no user session found to delete with id %d", id)
+        return ErrRecordNotFound
     }
 
     return nil
@@ -141,23 +263,45 @@ func (m UserSessionModel) DeleteUserSession(id int64) error {
 
 // ------------------- GET ALL -------------------
 
-func (m UserSessionModel) GetAllUserSessions() ([]*UserSession, error) {
+func (m UserSessionModel) GetAllUserSessions(scope *AccessScope) ([]*UserSession, error) {
+    query := `
+        SELECT id, trainee_id, session_id, credithours_completed, grade, feedback, completed,
+               COALESCE(override_reason, ''), COALESCE(overridden_by, 0), overridden_at, created_at, version,
+               ` + userSessionExpiresAt + `
+        FROM user_session us
+        WHERE ` + fmt.Sprintf(userSessionScopeFilter, 1, 2) + `
+        ORDER BY created_at DESC
+    `
+
+    return m.queryUserSessions(query, scope.National, pq.Array(scope.FormationIDs))
+}
+
+// Every session record for one trainee, newest first
+func (m UserSessionModel) GetAllForTrainee(traineeID int64) ([]*UserSession, error) {
     query := `
-        SELECT id, trainee_id, session_id, credithours_completed, grade, feedback, created_at, version
-        FROM user_session
+        SELECT id, trainee_id, session_id, credithours_completed, grade, feedback, completed,
+               COALESCE(override_reason, ''), COALESCE(overridden_by, 0), overridden_at, created_at, version,
+               ` + userSessionExpiresAt + `
+        FROM user_session us
+        WHERE trainee_id = $1
         ORDER BY created_at DESC
     `
 
+    return m.queryUserSessions(query, traineeID)
+}
+
+// Run a query selecting the user session columns in the order above
+func (m UserSessionModel) queryUserSessions(query string, args ...any) ([]*UserSession, error) {
     ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
     defer cancel()
 
-    rows, err := m.DB.QueryContext(ctx, query)
+    rows, err := m.DB.QueryContext(ctx, query, args...)
     if err != nil {
         return nil, err
     }
     defer rows.Close()
 
-    var sessions []*UserSession
+    sessions := []*UserSession{}
 
     for rows.Next() {
         var us UserSession
@@ -168,8 +312,13 @@ func (m UserSessionModel) GetAllUserSessions() ([]*UserSession, error) {
             &us.CreditHoursCompleted,
             &us.Grade,
             &us.Feedback,
+            &us.Completed,
+            &us.OverrideReason,
+            &us.OverriddenBy,
+            &us.OverriddenAt,
             &us.CreatedAt,
             &us.Version,
+            &us.ExpiresAt,
         )
         if err != nil {
             return nil, err
@@ -182,4 +331,404 @@ func (m UserSessionModel) GetAllUserSessions() ([]*UserSession, error) {
     }
 
     return sessions, nil
-}
\ No newline at end of file
+}
+// ------------------- CREDIT -------------------
+
+// Implemented by both *sql.DB and *sql.Tx so credit can be recalculated
+// inside the same transaction that changed the attendance
+type execer interface {
+    ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+    QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+    QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Work out credit hours and completion from attendance using the course's
+// completion policy. Trainees earn hours_per_day for each day present between
+// the session's start and end dates, and complete the course once they have
+// attended at least min_attendance_percent of the scheduled days. Days that
+// haven't had attendance taken yet count as absent, so nobody completes a
+// session part way through. Overridden rows are left alone. Webhook
+// subscribers are told about any completions gained or lost.
+func recalculateCredit(ctx context.Context, db execer, userSessionIDs []int64) error {
+    query := `
+        UPDATE user_session us
+        SET credithours_completed = stats.present * c.hours_per_day,
+            completed = stats.present * 100 >= stats.days * c.min_attendance_percent,
+            version = us.version + 1
+        FROM (
+            SELECT x.id,
+                   sx.ends_at::date - sx.starts_at::date + 1 AS days,
+                   COUNT(DISTINCT a.date) FILTER (
+                       WHERE a.attendance AND a.date BETWEEN sx.starts_at::date AND sx.ends_at::date
+                   ) AS present
+            FROM user_session x
+            INNER JOIN session sx ON sx.id = x.session_id
+            LEFT JOIN attendance a ON a.user_session_id = x.id
+            WHERE x.id = ANY($1)
+            GROUP BY x.id, sx.starts_at, sx.ends_at
+        ) stats, session s, course c
+        WHERE us.id = stats.id
+        AND s.id = us.session_id
+        AND c.id = s.course_id
+        AND us.override_reason IS NULL
+    `
+
+    _, err := db.ExecContext(ctx, query, pq.Array(userSessionIDs))
+    if err != nil {
+        return err
+    }
+
+    return notifyCompletionChanges(ctx, db, userSessionIDs)
+}
+
+// Recalculate credit hours and completion for everyone in a session, for
+// when its dates change
+func recalculateSessionCredit(ctx context.Context, db execer, sessionID int64) error {
+    query := `SELECT ARRAY(SELECT id FROM user_session WHERE session_id = $1)`
+
+    var ids []int64
+    err := db.QueryRowContext(ctx, query, sessionID).Scan(pq.Array(&ids))
+    if err != nil || len(ids) == 0 {
+        return err
+    }
+
+    return recalculateCredit(ctx, db, ids)
+}
+
+// Recalculate credit hours and completion from attendance
+func (m UserSessionModel) RecalculateCredit(userSessionIDs ...int64) error {
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    tx, err := m.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    err = recalculateCredit(ctx, tx, userSessionIDs)
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// Drop a manual override so the hours and completion go back to being
+// worked out from attendance
+func (m UserSessionModel) ClearOverride(id int64) error {
+    if id < 1 {
+        return ErrRecordNotFound
+    }
+
+    query := `
+        UPDATE user_session
+        SET override_reason = NULL, overridden_by = NULL, overridden_at = NULL, version = version + 1
+        WHERE id = $1
+    `
+
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    tx, err := m.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    result, err := tx.ExecContext(ctx, query, id)
+    if err != nil {
+        return err
+    }
+
+    rowsAffected, err := result.RowsAffected()
+    if err != nil {
+        return err
+    }
+
+    if rowsAffected == 0 {
+        return ErrRecordNotFound
+    }
+
+    err = recalculateCredit(ctx, tx, []int64{id})
+    if err != nil {
+        return err
+    }
+
+    return tx.Commit()
+}
+
+// ------------------- ENROLMENT -------------------
+
+// Whether a self-enrolment got a seat or joined the waitlist
+const (
+    EnrolmentStatusEnrolled   = "enrolled"
+    EnrolmentStatusWaitlisted = "waitlisted"
+)
+
+type Enrolment struct {
+    SessionID        int64  `json:"session_id"`
+    TraineeID        int64  `json:"trainee_id"`
+    Status           string `json:"status"`
+    UserSessionID    int64  `json:"user_session_id,omitempty"`
+    WaitlistPosition int    `json:"waitlist_position,omitempty"`
+}
+
+// Lock the session row for the rest of the transaction so that concurrent
+// changes to who is in the session are handled one at a time and can't
+// overbook it
+func lockSession(ctx context.Context, tx *sql.Tx, sessionID int64) (capacity int, status string, err error) {
+    query := `
+        SELECT capacity, status
+        FROM session
+        WHERE id = $1
+        AND deleted_at IS NULL
+        FOR UPDATE
+    `
+    err = tx.QueryRowContext(ctx, query, sessionID).Scan(&capacity, &status)
+    if err != nil {
+        switch {
+        case errors.Is(err, sql.ErrNoRows):
+            return 0, "", ErrRecordNotFound
+        default:
+            return 0, "", err
+        }
+    }
+
+    return capacity, status, nil
+}
+
+// Lock the session for self-enrolment. Only planned sessions can be enrolled
+// in or withdrawn from.
+func lockSessionForEnrolment(ctx context.Context, tx *sql.Tx, sessionID int64) (capacity int, err error) {
+    capacity, status, err := lockSession(ctx, tx, sessionID)
+    if err != nil {
+        return 0, err
+    }
+
+    if status != SessionStatusPlanned {
+        return 0, ErrSessionClosed
+    }
+
+    return capacity, nil
+}
+
+// Take a seat in the session if there is one (a capacity of 0 means no
+// limit), otherwise join the end of the waitlist
+func (m UserSessionModel) Enroll(sessionID, traineeID int64) (*Enrolment, error) {
+    if sessionID < 1 {
+        return nil, ErrRecordNotFound
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    tx, err := m.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    capacity, err := lockSessionForEnrolment(ctx, tx, sessionID)
+    if err != nil {
+        return nil, err
+    }
+
+    var exists bool
+    query := `
+        SELECT EXISTS (SELECT 1 FROM user_session WHERE session_id = $1 AND trainee_id = $2)
+            OR EXISTS (SELECT 1 FROM session_waitlist WHERE session_id = $1 AND user_id = $2)
+    `
+    err = tx.QueryRowContext(ctx, query, sessionID, traineeID).Scan(&exists)
+    if err != nil {
+        return nil, err
+    }
+    if exists {
+        return nil, ErrAlreadyEnrolled
+    }
+
+    var taken int
+    query = `SELECT COUNT(*) FROM user_session WHERE session_id = $1`
+    err = tx.QueryRowContext(ctx, query, sessionID).Scan(&taken)
+    if err != nil {
+        return nil, err
+    }
+
+    enrolment := &Enrolment{
+        SessionID: sessionID,
+        TraineeID: traineeID,
+    }
+
+    if capacity == 0 || taken < capacity {
+        enrolment.Status = EnrolmentStatusEnrolled
+        enrolment.UserSessionID, err = insertEnrolment(ctx, tx, sessionID, traineeID)
+        if err != nil {
+            return nil, err
+        }
+    } else {
+        enrolment.Status = EnrolmentStatusWaitlisted
+        query = `
+            INSERT INTO session_waitlist (session_id, user_id)
+            VALUES ($1, $2)
+            RETURNING (SELECT COUNT(*) + 1 FROM session_waitlist WHERE session_id = $1)
+        `
+        err = tx.QueryRowContext(ctx, query, sessionID, traineeID).Scan(&enrolment.WaitlistPosition)
+        if err != nil {
+            return nil, err
+        }
+    }
+
+    err = tx.Commit()
+    if err != nil {
+        return nil, err
+    }
+
+    return enrolment, nil
+}
+
+// Give up a seat or a place on the waitlist. When a seat is freed the officer
+// at the front of the waitlist is enrolled in it and their id is returned.
+func (m UserSessionModel) Withdraw(sessionID, traineeID int64) (promotedID int64, err error) {
+    if sessionID < 1 {
+        return 0, ErrRecordNotFound
+    }
+
+    ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+    defer cancel()
+
+    tx, err := m.DB.BeginTx(ctx, nil)
+    if err != nil {
+        return 0, err
+    }
+    defer tx.Rollback()
+
+    capacity, err := lockSessionForEnrolment(ctx, tx, sessionID)
+    if err != nil {
+        return 0, err
+    }
+
+    query := `DELETE FROM user_session WHERE session_id = $1 AND trainee_id = $2`
+    result, err := tx.ExecContext(ctx, query, sessionID, traineeID)
+    if err != nil {
+        return 0, err
+    }
+    seatsFreed, err := result.RowsAffected()
+    if err != nil {
+        return 0, err
+    }
+
+    if seatsFreed == 0 {
+        query = `DELETE FROM session_waitlist WHERE session_id = $1 AND user_id = $2`
+        result, err = tx.ExecContext(ctx, query, sessionID, traineeID)
+        if err != nil {
+            return 0, err
+        }
+        rowsAffected, err := result.RowsAffected()
+        if err != nil {
+            return 0, err
+        }
+        if rowsAffected == 0 {
+            return 0, ErrRecordNotFound
+        }
+        return 0, tx.Commit()
+    }
+
+    // The session may have been filled past capacity by an admin, only
+    // promote if there really is a seat free now
+    var taken int
+    query = `SELECT COUNT(*) FROM user_session WHERE session_id = $1`
+    err = tx.QueryRowContext(ctx, query, sessionID).Scan(&taken)
+    if err != nil {
+        return 0, err
+    }
+
+    if capacity == 0 || taken < capacity {
+        promotedID, err = promoteFromWaitlist(ctx, tx, sessionID)
+        if err != nil {
+            return 0, err
+        }
+    }
+
+    err = tx.Commit()
+    if err != nil {
+        return 0, err
+    }
+
+    return promotedID, nil
+}
+
+// Enrol the first officer on the waitlist who can still take the course.
+// Officers who have since been deleted or no longer meet the course's
+// prerequisites keep their place but are passed over. Returns 0 when
+// nobody on the waitlist can be promoted.
+func promoteFromWaitlist(ctx context.Context, tx *sql.Tx, sessionID int64) (int64, error) {
+    var courseID int64
+    query := `SELECT course_id FROM session WHERE id = $1`
+    err := tx.QueryRowContext(ctx, query, sessionID).Scan(&courseID)
+    if err != nil {
+        return 0, err
+    }
+
+    query = `
+        SELECT w.user_id
+        FROM session_waitlist w
+        INNER JOIN users u ON u.id = w.user_id
+        WHERE w.session_id = $1
+        AND u.deleted_at IS NULL
+        ORDER BY w.id ASC
+    `
+    rows, err := tx.QueryContext(ctx, query, sessionID)
+    if err != nil {
+        return 0, err
+    }
+    var waiting []int64
+    for rows.Next() {
+        var userID int64
+        err := rows.Scan(&userID)
+        if err != nil {
+            rows.Close()
+            return 0, err
+        }
+        waiting = append(waiting, userID)
+    }
+    rows.Close()
+    if err = rows.Err(); err != nil {
+        return 0, err
+    }
+
+    for _, userID := range waiting {
+        eligibility, err := checkEligibility(ctx, tx, courseID, userID)
+        if err != nil {
+            return 0, err
+        }
+        if !eligibility.Eligible {
+            continue
+        }
+
+        query = `DELETE FROM session_waitlist WHERE session_id = $1 AND user_id = $2`
+        _, err = tx.ExecContext(ctx, query, sessionID, userID)
+        if err != nil {
+            return 0, err
+        }
+
+        _, err = insertEnrolment(ctx, tx, sessionID, userID)
+        if err != nil {
+            return 0, err
+        }
+        return userID, nil
+    }
+
+    return 0, nil
+}
+
+// Seats taken by enrolment start with no hours, grade or feedback
+func insertEnrolment(ctx context.Context, tx *sql.Tx, sessionID, traineeID int64) (int64, error) {
+    var id int64
+    query := `
+        INSERT INTO user_session (trainee_id, session_id, grade, feedback)
+        VALUES ($1, $2, '', '')
+        RETURNING id
+    `
+    err := tx.QueryRowContext(ctx, query, traineeID, sessionID).Scan(&id)
+    return id, err
+}
@@ -9,28 +9,31 @@ import (
 	"fmt"
 	"time"
 	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 )
 
 // Duplicate error message
 var ErrDuplicateEmail = errors.New("duplicate email")
+var ErrDuplicateUsername = errors.New("duplicate username")
 var AnonymousUser = &User{}
 
 type User struct {
-	ID               int64     `json:"id"`
-	RegulationNumber string    `json:"regulation_number"`
-	Username         string    `json:"username"`
-	FName            string    `json:"fname"`
-	LName            string    `json:"lname"`
-	Email            string    `json:"email"`
-	Gender           string    `json:"gender"`
-	Formation        int       `json:"formation"`
-	Rank             int       `json:"rank"`
-	Postings         int       `json:"postings"`
-	Password         password  `json:"-"`
-	Activated        bool      `json:"activated"`
-	Version          int       `json:"-"`
-	CreatedAt        time.Time `json:"created_at"`
+	ID               int64      `json:"id"`
+	RegulationNumber string     `json:"regulation_number"`
+	Username         string     `json:"username"`
+	FName            string     `json:"fname"`
+	LName            string     `json:"lname"`
+	Email            string     `json:"email"`
+	Gender           string     `json:"gender"`
+	Formation        int        `json:"formation"`
+	Rank             int        `json:"rank"`
+	Postings         int        `json:"postings"`
+	Password         password   `json:"-"`
+	Activated        bool       `json:"activated"`
+	Version          int        `json:"-"`
+	CreatedAt        time.Time  `json:"created_at"`
+	DeletedAt        *time.Time `json:"deleted_at,omitempty"`
 }
 
 type password struct {
@@ -114,6 +117,23 @@ func ValidateUser(v *validator.Validator, user User) {
 	}
 }
 
+// Turn a unique violation on the users table into the matching error
+func duplicateUserError(err error) error {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+
+	switch pqErr.Constraint {
+	case "users_email_key":
+		return ErrDuplicateEmail
+	case "users_username_key":
+		return ErrDuplicateUsername
+	default:
+		return err
+	}
+}
+
 // Inserty a new user into the db
 func (u UserModel) Insert(user *User) error {
 	query := `
@@ -131,17 +151,75 @@ func (u UserModel) Insert(user *User) error {
 	err := u.DB.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Version)
 
 	if err != nil {
-		switch {
-		case err.Error() == `pq: duplicate key value violated unique constraint "users_email_key"`:
-			return ErrDuplicateEmail
-		default:
-			return err
-		}
+		return duplicateUserError(err)
 	}
 
 	return nil
 }
 
+// Sign up a new user. The user, their starting permissions, an activation
+// token and the welcome email carrying it are all saved in one transaction
+// so that nobody is left registered without a way to activate.
+func (u UserModel) Register(user *User, activationTTL time.Duration, permissions ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := u.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+			INSERT INTO users (regulation_number, username, fname, lname, email, password_hash, activated, gender, formation_id, rank_id, posting_id)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id, created_at, version
+			`
+
+	args := []any{user.RegulationNumber, user.Username, user.FName, user.LName, user.Email, user.Password.hash, user.Activated, user.Gender, user.Formation, user.Rank, user.Postings}
+
+	err = tx.QueryRowContext(ctx, query, args...).Scan(&user.ID, &user.CreatedAt, &user.Version)
+	if err != nil {
+		return duplicateUserError(err)
+	}
+
+	query = `
+			INSERT INTO users_permissions (user_id, permission_id)
+			SELECT $1, permissions.id FROM permissions
+			WHERE permissions.code = ANY($2)
+			ON CONFLICT (user_id, permission_id) DO NOTHING
+			`
+
+	_, err = tx.ExecContext(ctx, query, user.ID, pq.Array(permissions))
+	if err != nil {
+		return err
+	}
+
+	token, err := generateToken(user.ID, activationTTL, ScopeActivation)
+	if err != nil {
+		return err
+	}
+
+	err = insertToken(ctx, tx, token)
+	if err != nil {
+		return err
+	}
+
+	err = enqueueEmail(ctx, tx, &Email{
+		Recipient: user.Email,
+		Template:  "user_welcome.tmpl",
+		Data: map[string]any{
+			"activationToken": token.Plaintext,
+			"userID":          user.ID,
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
 // Get a user from the db based on their email provided
 func (u UserModel) GetByEmail(email string) (*User, error) {
 	query := `
@@ -149,6 +227,7 @@ func (u UserModel) GetByEmail(email string) (*User, error) {
 		       activated, gender, formation_id, rank_id, posting_id, version, created_at
 			FROM users
 			WHERE email = $1
+			AND deleted_at IS NULL
 			`
 
 	var user User
@@ -217,12 +296,10 @@ func (u UserModel) Update(user *User) error {
 	// Check for errors during update
 	if err != nil {
 		switch {
-		case err.Error() == `pq: duplicate key value violated unique constraint "users_email_key"`:
-			return ErrDuplicateEmail
 		case errors.Is(err, sql.ErrNoRows):
 			return ErrEditConflict
 		default:
-			return err
+			return duplicateUserError(err)
 		}
 	}
 	return nil
@@ -242,6 +319,7 @@ func (u UserModel) GetForToken(tokenScope, tokenPlaintext string) (*User, error)
         WHERE tokens.hash = $1
         AND tokens.scope = $2 
         AND tokens.expiry > $3
+        AND users.deleted_at IS NULL
 	`
 	args := []any{tokenHash[:], tokenScope, time.Now()}
 	var user User
@@ -287,14 +365,17 @@ func (u UserModel) Activate(user *User) error {
 	return u.DB.QueryRowContext(ctx, query, user.ID, user.Version).Scan(&user.Version)
 }
 
-// Get all users from the database
-func (u UserModel) GetAll(id int64, regNumber, username, fname, lname, email, gender string, formation, rank, postings int, filters Filters) ([]*User, Metadata, error) {
+// Get all users from the database that fall within the scope. Deleted
+// users are left out unless includeDeleted is set.
+func (u UserModel) GetAll(id int64, regNumber, username, fname, lname, email, gender string, formation, rank, postings int, includeDeleted bool, filters Filters, scope *AccessScope) ([]*User, Metadata, error) {
 	// Build query using these parameters
 	query := fmt.Sprintf(`
         SELECT COUNT(*) OVER(), id, regulation_number, username, fname, lname, email, 
-               gender, formation_id, rank_id, posting_id
+               gender, formation_id, rank_id, posting_id, deleted_at
         FROM users
         WHERE (to_tsvector('simple', username) @@ plainto_tsquery('simple', $1) OR $1 = '')
+        AND ($4 OR formation_id = ANY($5))
+        AND ($6 OR deleted_at IS NULL)
         ORDER BY %s %s, id ASC
         LIMIT $2 OFFSET $3`,
 		filters.sortColumn(), filters.sortDirection())
@@ -302,8 +383,8 @@ func (u UserModel) GetAll(id int64, regNumber, username, fname, lname, email, ge
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	// Use username for $1, then page size and offset
-	rows, err := u.DB.QueryContext(ctx, query, username, filters.PageSize, (filters.Page-1)*filters.PageSize)
+	// Use username for $1, then page size and offset, then the scope
+	rows, err := u.DB.QueryContext(ctx, query, username, filters.PageSize, (filters.Page-1)*filters.PageSize, scope.National, pq.Array(scope.FormationIDs), includeDeleted)
 	if err != nil {
 		return nil, Metadata{}, err
 	}
@@ -326,6 +407,7 @@ func (u UserModel) GetAll(id int64, regNumber, username, fname, lname, email, ge
 			&user.Formation,
 			&user.Rank,
 			&user.Postings,
+			&user.DeletedAt,
 		)
 		if err != nil {
 			return nil, Metadata{}, err
@@ -370,8 +452,9 @@ func (u UserModel) UpdateUser(user *User) error {
 
 }
 
-// Delete user
-func (u UserModel) Delete(id int64) error {
+// Mark a user within the scope as deleted. They can no longer sign in but
+// their training history is kept, and they can be restored until purged.
+func (u UserModel) Delete(id int64, scope *AccessScope) error {
 
 	// Check
 	if id < 1 {
@@ -380,8 +463,11 @@ func (u UserModel) Delete(id int64) error {
 
 	// the SQL query to be executed
 	query := `
-		DELETE FROM users
+		UPDATE users
+		SET deleted_at = NOW()
 		WHERE id = $1
+		AND ($2 OR formation_id = ANY($3))
+		AND deleted_at IS NULL
 		`
 
 	// Context with a 3-second timeout
@@ -389,7 +475,7 @@ func (u UserModel) Delete(id int64) error {
 	defer cancel()
 
 	// execute query against the database
-	result, err := u.DB.ExecContext(ctx, query, id)
+	result, err := u.DB.ExecContext(ctx, query, id, scope.National, pq.Array(scope.FormationIDs))
 	if err != nil {
 		return err
 	}
@@ -407,8 +493,64 @@ func (u UserModel) Delete(id int64) error {
 	return nil
 }
 
-// Get by id
-func (u UserModel) GetByID(id int64) (*User, error) {
+// Bring back a deleted user within the scope
+func (u UserModel) Restore(id int64, scope *AccessScope) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		UPDATE users
+		SET deleted_at = NULL
+		WHERE id = $1
+		AND ($2 OR formation_id = ANY($3))
+		AND deleted_at IS NOT NULL
+		`
+
+	// Context with a 3-second timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := u.DB.ExecContext(ctx, query, id, scope.National, pq.Array(scope.FormationIDs))
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Permanently remove users deleted before the cutoff, along with their
+// training history. Returns how many were removed.
+func (u UserModel) Purge(deletedBefore time.Time) (int64, error) {
+	query := `
+		DELETE FROM users
+		WHERE deleted_at < $1
+		`
+
+	// Context with a 3-second timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := u.DB.ExecContext(ctx, query, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Get by id. Users outside the scope are reported as not found, as are
+// deleted ones unless includeDeleted is set.
+func (u UserModel) GetByID(id int64, scope *AccessScope, includeDeleted bool) (*User, error) {
 	// Check if the id is valid
 	if id < 1 {
 		return nil, ErrRecordNotFound
@@ -416,9 +558,11 @@ func (u UserModel) GetByID(id int64) (*User, error) {
 
 	query := `
 		SELECT id, regulation_number, username, fname, lname, email, password_hash,
-		       activated, gender, formation_id, rank_id, posting_id, version, created_at
+		       activated, gender, formation_id, rank_id, posting_id, version, created_at, deleted_at
 		FROM users
 		WHERE id = $1
+		AND ($2 OR formation_id = ANY($3))
+		AND ($4 OR deleted_at IS NULL)
 		`
 
 	var user User
@@ -427,7 +571,7 @@ func (u UserModel) GetByID(id int64) (*User, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
 
-	err := u.DB.QueryRowContext(ctx, query, id).Scan(
+	err := u.DB.QueryRowContext(ctx, query, id, scope.National, pq.Array(scope.FormationIDs), includeDeleted).Scan(
 		&user.ID,
 		&user.RegulationNumber,
 		&user.Username,
@@ -442,6 +586,7 @@ func (u UserModel) GetByID(id int64) (*User, error) {
 		&user.Postings,
 		&user.Version,
 		&user.CreatedAt,
+		&user.DeletedAt,
 	)
 	if err != nil {
 		switch {
@@ -479,3 +624,51 @@ func (u UserModel) UpdatePassword(id int64, newPassword string) error {
 
 	return nil
 }
+
+// Set a new password from a reset token. The new hash is saved and the
+// user's reset and authentication tokens are removed in one transaction,
+// so the reset token can't be reused and old sign-ins can't survive a
+// reset that failed halfway.
+func (u UserModel) ResetPassword(id int64, newPassword string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	tx, err := u.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users
+		SET password_hash = $1, version = version + 1
+		WHERE id = $2`
+
+	result, err := tx.ExecContext(ctx, query, hashedPassword, id)
+	if err != nil {
+		return err
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	query = `
+		DELETE FROM tokens
+		WHERE user_id = $1 AND scope = ANY($2)`
+
+	_, err = tx.ExecContext(ctx, query, id, pq.Array([]string{ScopePasswordReset, ScopeAuthentication}))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
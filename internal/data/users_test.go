@@ -0,0 +1,67 @@
+// Filename: internal/data/users_test.go
+package data
+
+import (
+    "context"
+    "database/sql"
+    "database/sql/driver"
+    "errors"
+    "testing"
+
+    "github.com/lib/pq"
+)
+
+// A database driver whose every query fails with the given error, standing
+// in for Postgres rejecting a write
+type failingDriver struct{ err error }
+
+func (d failingDriver) Open(string) (driver.Conn, error) { return failingConn(d), nil }
+
+type failingConn struct{ err error }
+
+func (c failingConn) Prepare(string) (driver.Stmt, error) { return nil, c.err }
+func (c failingConn) Close() error                        { return nil }
+func (c failingConn) Begin() (driver.Tx, error)           { return nil, c.err }
+
+func (c failingConn) QueryContext(context.Context, string, []driver.NamedValue) (driver.Rows, error) {
+    return nil, c.err
+}
+
+func openFailingDB(t *testing.T, err error) *sql.DB {
+    name := "failing-" + t.Name()
+    sql.Register(name, failingDriver{err: err})
+
+    db, openErr := sql.Open(name, "")
+    if openErr != nil {
+        t.Fatal(openErr)
+    }
+    t.Cleanup(func() { db.Close() })
+    return db
+}
+
+func TestUserModelUpdate_DuplicateKeys(t *testing.T) {
+    tests := []struct {
+        constraint string
+        want       error
+    }{
+        {"users_email_key", ErrDuplicateEmail},
+        {"users_username_key", ErrDuplicateUsername},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.constraint, func(t *testing.T) {
+            db := openFailingDB(t, &pq.Error{Code: "23505", Constraint: tt.constraint})
+            model := UserModel{DB: db}
+
+            err := model.Update(&User{ID: 1, Version: 1})
+            if !errors.Is(err, tt.want) {
+                t.Fatalf("expected %v; got %v", tt.want, err)
+            }
+
+            err = model.Insert(&User{})
+            if !errors.Is(err, tt.want) {
+                t.Fatalf("expected %v from insert; got %v", tt.want, err)
+            }
+        })
+    }
+}
@@ -0,0 +1,588 @@
+// Filename: internal/data/webhook.go
+package data
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/kelseyaban/National-Inservice-Training-Database/internal/validator"
+	"github.com/lib/pq"
+)
+
+// The events other systems can subscribe to. user_session.revoked is sent
+// when a completion is taken away, by corrected attendance or by hand, and
+// compliance.failed when an officer's qualification in a mandatory course
+// lapses. Ping is only ever sent to one subscription, on request, to test it.
+const (
+	WebhookEventUserSessionCompleted = "user_session.completed"
+	WebhookEventUserSessionRevoked   = "user_session.revoked"
+	WebhookEventComplianceFailed     = "compliance.failed"
+	WebhookEventSessionCreated       = "session.created"
+	WebhookEventAttendanceRecorded   = "attendance.recorded"
+	WebhookEventUserActivated        = "user.activated"
+	WebhookEventPing                 = "ping"
+)
+
+var WebhookEvents = []string{
+	WebhookEventUserSessionCompleted,
+	WebhookEventUserSessionRevoked,
+	WebhookEventComplianceFailed,
+	WebhookEventSessionCreated,
+	WebhookEventAttendanceRecorded,
+	WebhookEventUserActivated,
+}
+
+// Where a delivery is. Failed deliveries have run out of attempts.
+const (
+	WebhookDeliveryPending   = "pending"
+	WebhookDeliveryDelivered = "delivered"
+	WebhookDeliveryFailed    = "failed"
+)
+
+var WebhookDeliveryStatuses = []string{WebhookDeliveryPending, WebhookDeliveryDelivered, WebhookDeliveryFailed}
+
+// A system that wants to hear about some events. The secret is only shown
+// when the subscription is created or the secret is rotated.
+type WebhookSubscription struct {
+	ID          int64     `json:"id"`
+	URL         string    `json:"url"`
+	Secret      string    `json:"secret,omitempty"`
+	Events      []string  `json:"events"`
+	Description string    `json:"description"`
+	Active      bool      `json:"active"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Performs the validation checks
+func ValidateWebhookSubscription(v *validator.Validator, subscription *WebhookSubscription) {
+	v.Check(subscription.URL != "", "url", "must be provided")
+	v.Check(len(subscription.URL) <= 2000, "url", "must not be more than 2000 bytes long")
+	if subscription.URL != "" {
+		u, err := url.Parse(subscription.URL)
+		v.Check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "url", "must be an absolute http or https URL")
+	}
+
+	v.Check(len(subscription.Events) > 0, "events", "must contain at least one event")
+	seen := make(map[string]bool, len(subscription.Events))
+	for _, event := range subscription.Events {
+		v.Check(validator.PermittedValue(event, WebhookEvents...), "events", "must only contain user_session.completed, user_session.revoked, compliance.failed, session.created, attendance.recorded or user.activated")
+		v.Check(!seen[event], "events", "must not contain duplicate events")
+		seen[event] = true
+	}
+
+	v.Check(len(subscription.Description) <= 500, "description", "must not be more than 500 bytes long")
+}
+
+// A random secret for signing deliveries
+func generateWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// One event sent, or waiting to be sent, to one subscription. URL and
+// Secret come from the subscription and are only filled in for sending.
+// DedupKey is set for events that must only be sent once.
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	SubscriptionID int64           `json:"subscription_id"`
+	Event          string          `json:"event"`
+	DedupKey       string          `json:"dedup_key,omitempty"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	LastStatusCode int             `json:"last_status_code,omitempty"`
+	LastError      string          `json:"last_error,omitempty"`
+	NextAttemptAt  time.Time       `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	URL            string          `json:"-"`
+	Secret         string          `json:"-"`
+}
+
+type WebhookModel struct {
+	DB *sql.DB
+}
+
+// Queue an event for every active subscription that wants it using db,
+// which can be the transaction making the change the event is about
+func enqueueWebhookEvent(ctx context.Context, db execer, event string, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO webhook_deliveries (subscription_id, event, payload)
+		SELECT id, $1::text, $2::jsonb
+		FROM webhook_subscriptions
+		WHERE active AND $1 = ANY(events)`
+
+	_, err = db.ExecContext(ctx, query, event, data)
+	return err
+}
+
+// Queue an event on its own
+func (m WebhookModel) Enqueue(event string, payload any) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return enqueueWebhookEvent(ctx, m.DB, event, payload)
+}
+
+// Queue user_session.completed or user_session.revoked for any of the user
+// sessions whose completion has changed since subscribers were last told,
+// using db, which should be the transaction that made the change. The row
+// lock taken by the update means concurrent changes can't both send the same
+// event, and the dedup key, the event with the user session and its version,
+// stops it being queued again if the statement is repeated.
+func notifyCompletionChanges(ctx context.Context, db execer, userSessionIDs []int64) error {
+	query := `
+		WITH u AS (
+			UPDATE user_session us
+			SET completion_notified = us.completed
+			FROM session s
+			WHERE us.id = ANY($1)
+			AND s.id = us.session_id
+			AND us.completed <> us.completion_notified
+			RETURNING us.id, us.trainee_id, us.session_id, us.credithours_completed, us.version, s.course_id,
+			          CASE WHEN us.completed THEN $2::text ELSE $3::text END AS event
+		)
+		INSERT INTO webhook_deliveries (subscription_id, event, payload, dedup_key)
+		SELECT w.id, u.event,
+		       jsonb_build_object(
+		           'user_session_id', u.id,
+		           'trainee_id', u.trainee_id,
+		           'session_id', u.session_id,
+		           'course_id', u.course_id,
+		           'credithours_completed', u.credithours_completed),
+		       u.event || ':' || u.id || ':' || u.version
+		FROM u
+		INNER JOIN webhook_subscriptions w ON w.active AND u.event = ANY(w.events)
+		ON CONFLICT (subscription_id, dedup_key) DO NOTHING`
+
+	_, err := db.ExecContext(ctx, query, pq.Array(userSessionIDs), WebhookEventUserSessionCompleted, WebhookEventUserSessionRevoked)
+	return err
+}
+
+// Queue compliance.failed for officers whose most recent completion of a
+// course that is mandatory for their posting and rank has lapsed. Each lapsed
+// completion is only sent once, and only to subscriptions that existed when
+// it lapsed so that a new subscription isn't sent the whole history. Returns
+// how many deliveries were queued.
+func (m WebhookModel) EnqueueComplianceFailures() (int64, error) {
+	query := `
+		WITH latest AS (
+			SELECT DISTINCT ON (us.trainee_id, s.course_id)
+			       us.id, us.trainee_id, s.course_id, ` + courseCompletionExpiry + ` AS expires_at
+			FROM user_session us
+			INNER JOIN session s ON s.id = us.session_id
+			INNER JOIN course c ON c.id = s.course_id AND c.deleted_at IS NULL
+			WHERE us.completed AND c.validity_months > 0
+			ORDER BY us.trainee_id, s.course_id, s.ends_at DESC
+		)
+		INSERT INTO webhook_deliveries (subscription_id, event, payload, dedup_key)
+		SELECT w.id, $1::text,
+		       jsonb_build_object(
+		           'user_id', u.id,
+		           'formation_id', u.formation_id,
+		           'course_id', l.course_id,
+		           'user_session_id', l.id,
+		           'expired_at', l.expires_at),
+		       $1::text || ':' || l.id
+		FROM latest l
+		INNER JOIN users u ON u.id = l.trainee_id AND u.deleted_at IS NULL
+		INNER JOIN course_posting cp ON cp.posting_id = u.posting_id AND cp.rank_id = u.rank_id
+		      AND cp.course_id = l.course_id AND cp.mandatory
+		INNER JOIN webhook_subscriptions w ON w.active AND $1 = ANY(w.events) AND w.created_at <= l.expires_at
+		WHERE l.expires_at <= NOW()
+		ON CONFLICT (subscription_id, dedup_key) DO NOTHING`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, WebhookEventComplianceFailed)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// Add a subscription with a new secret
+func (m WebhookModel) Insert(subscription *WebhookSubscription) error {
+	secret, err := generateWebhookSecret()
+	if err != nil {
+		return err
+	}
+	subscription.Secret = secret
+
+	query := `
+		INSERT INTO webhook_subscriptions (url, secret, events, description, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at`
+
+	args := []any{subscription.URL, subscription.Secret, pq.Array(subscription.Events), subscription.Description, subscription.Active}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, args...).Scan(&subscription.ID, &subscription.CreatedAt)
+}
+
+// Get a subscription, without its secret
+func (m WebhookModel) Get(id int64) (*WebhookSubscription, error) {
+	if id < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		SELECT id, url, events, description, active, created_at
+		FROM webhook_subscriptions
+		WHERE id = $1`
+
+	var subscription WebhookSubscription
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, id).Scan(
+		&subscription.ID,
+		&subscription.URL,
+		pq.Array(&subscription.Events),
+		&subscription.Description,
+		&subscription.Active,
+		&subscription.CreatedAt,
+	)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+
+	return &subscription, nil
+}
+
+// Update a subscription. A new secret is made when rotateSecret is set.
+func (m WebhookModel) Update(subscription *WebhookSubscription, rotateSecret bool) error {
+	subscription.Secret = ""
+	if rotateSecret {
+		secret, err := generateWebhookSecret()
+		if err != nil {
+			return err
+		}
+		subscription.Secret = secret
+	}
+
+	query := `
+		UPDATE webhook_subscriptions
+		SET url = $1, events = $2, description = $3, active = $4,
+		    secret = COALESCE(NULLIF($5, ''), secret)
+		WHERE id = $6
+		RETURNING created_at`
+
+	args := []any{subscription.URL, pq.Array(subscription.Events), subscription.Description, subscription.Active, subscription.Secret, subscription.ID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	err := m.DB.QueryRowContext(ctx, query, args...).Scan(&subscription.CreatedAt)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return ErrRecordNotFound
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Delete a subscription along with its delivery log
+func (m WebhookModel) Delete(id int64) error {
+	if id < 1 {
+		return ErrRecordNotFound
+	}
+
+	query := `
+		DELETE FROM webhook_subscriptions
+		WHERE id = $1`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	result, err := m.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+// Get all subscriptions, without their secrets
+func (m WebhookModel) GetAll(filters Filters) ([]*WebhookSubscription, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT COUNT(*) OVER(), id, url, events, description, active, created_at
+		FROM webhook_subscriptions
+		ORDER BY %s %s, id ASC
+		LIMIT $1 OFFSET $2`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	subscriptions := []*WebhookSubscription{}
+
+	for rows.Next() {
+		var subscription WebhookSubscription
+		err := rows.Scan(
+			&totalRecords,
+			&subscription.ID,
+			&subscription.URL,
+			pq.Array(&subscription.Events),
+			&subscription.Description,
+			&subscription.Active,
+			&subscription.CreatedAt,
+		)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		subscriptions = append(subscriptions, &subscription)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return subscriptions, metadata, nil
+}
+
+// The columns scanned by scanWebhookDelivery, for webhook_deliveries aliased d
+const webhookDeliveryColumns = `d.id, d.subscription_id, d.event, COALESCE(d.dedup_key, ''), d.payload, d.status, d.attempts,
+	d.last_status_code, d.last_error, d.next_attempt_at, d.delivered_at, d.created_at`
+
+func scanWebhookDelivery(row rowScanner, extra ...any) (*WebhookDelivery, error) {
+	var delivery WebhookDelivery
+	var payload []byte
+	dest := append([]any{
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.Event,
+		&delivery.DedupKey,
+		&payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+	}, extra...)
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+
+	return &delivery, nil
+}
+
+// Take up to limit pending deliveries for active subscriptions that are due.
+// They are pushed back by lease while they are being sent, the same way as
+// emails in the outbox.
+func (m WebhookModel) ClaimDue(limit int, lease time.Duration) ([]*WebhookDelivery, error) {
+	query := `
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = NOW() + make_interval(secs => $2)
+		FROM webhook_subscriptions w
+		WHERE w.id = d.subscription_id
+		AND d.id IN (
+			SELECT x.id
+			FROM webhook_deliveries x
+			INNER JOIN webhook_subscriptions s ON s.id = x.subscription_id AND s.active
+			WHERE x.status = 'pending' AND x.next_attempt_at <= NOW()
+			ORDER BY x.next_attempt_at ASC, x.id ASC
+			LIMIT $1
+			FOR UPDATE OF x SKIP LOCKED
+		)
+		RETURNING ` + webhookDeliveryColumns + `, w.url, w.secret`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, limit, lease.Seconds())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	deliveries := []*WebhookDelivery{}
+	for rows.Next() {
+		var url, secret string
+		delivery, err := scanWebhookDelivery(rows, &url, &secret)
+		if err != nil {
+			return nil, err
+		}
+		delivery.URL = url
+		delivery.Secret = secret
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+// Queue a ping for one subscription, whether or not it is active, ready to
+// be sent straight away
+func (m WebhookModel) CreatePing(subscriptionID int64) (*WebhookDelivery, error) {
+	if subscriptionID < 1 {
+		return nil, ErrRecordNotFound
+	}
+
+	query := `
+		WITH d AS (
+			INSERT INTO webhook_deliveries (subscription_id, event, payload, next_attempt_at)
+			SELECT id, $2::text, jsonb_build_object('webhook_id', id), NOW() + INTERVAL '1 hour'
+			FROM webhook_subscriptions
+			WHERE id = $1
+			RETURNING *
+		)
+		SELECT ` + webhookDeliveryColumns + `, w.url, w.secret
+		FROM d
+		INNER JOIN webhook_subscriptions w ON w.id = d.subscription_id`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var url, secret string
+	delivery, err := scanWebhookDelivery(m.DB.QueryRowContext(ctx, query, subscriptionID, WebhookEventPing), &url, &secret)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return nil, ErrRecordNotFound
+		default:
+			return nil, err
+		}
+	}
+	delivery.URL = url
+	delivery.Secret = secret
+
+	return delivery, nil
+}
+
+// Mark a delivery as delivered
+func (m WebhookModel) MarkDelivered(delivery *WebhookDelivery, statusCode int) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET status = 'delivered', attempts = attempts + 1, last_status_code = $2, last_error = '', delivered_at = NOW()
+		WHERE id = $1
+		RETURNING status, attempts, last_status_code, last_error, delivered_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, delivery.ID, statusCode).Scan(
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.DeliveredAt,
+	)
+}
+
+// Record a failed attempt. The delivery is tried again at nextAttemptAt, or
+// moved to failed when deadLetter is set.
+func (m WebhookModel) RecordFailure(delivery *WebhookDelivery, statusCode int, lastError string, nextAttemptAt time.Time, deadLetter bool) error {
+	query := `
+		UPDATE webhook_deliveries
+		SET attempts = attempts + 1, last_status_code = $2, last_error = $3, next_attempt_at = $4,
+		    status = CASE WHEN $5 THEN 'failed' ELSE status END
+		WHERE id = $1
+		RETURNING status, attempts, last_status_code, last_error, next_attempt_at`
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	return m.DB.QueryRowContext(ctx, query, delivery.ID, statusCode, lastError, nextAttemptAt, deadLetter).Scan(
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.LastStatusCode,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+	)
+}
+
+// The delivery log for a subscription, optionally only those with the given
+// status
+func (m WebhookModel) GetDeliveries(subscriptionID int64, status string, filters Filters) ([]*WebhookDelivery, Metadata, error) {
+	query := fmt.Sprintf(`
+		SELECT `+webhookDeliveryColumns+`, COUNT(*) OVER()
+		FROM webhook_deliveries d
+		WHERE d.subscription_id = $1
+		AND ($2 = '' OR d.status = $2)
+		ORDER BY d.%s %s, d.id DESC
+		LIMIT $3 OFFSET $4`, filters.sortColumn(), filters.sortDirection())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	rows, err := m.DB.QueryContext(ctx, query, subscriptionID, status, filters.limit(), filters.offset())
+	if err != nil {
+		return nil, Metadata{}, err
+	}
+	defer rows.Close()
+
+	totalRecords := 0
+	deliveries := []*WebhookDelivery{}
+
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows, &totalRecords)
+		if err != nil {
+			return nil, Metadata{}, err
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, Metadata{}, err
+	}
+
+	metadata := calculateMetadata(totalRecords, filters.Page, filters.PageSize)
+
+	return deliveries, metadata, nil
+}
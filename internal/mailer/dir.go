@@ -0,0 +1,59 @@
+// Filename: internal/mailer/dir.go
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// Saves each email as a .eml file in a directory instead of sending it. The
+// files open in any mail client.
+type DirMailer struct {
+	dir    string
+	sender string
+	count  atomic.Int64
+}
+
+// Create the directory if it doesn't exist yet
+func NewDir(dir, sender string) (*DirMailer, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DirMailer{
+		dir:    dir,
+		sender: sender,
+	}, nil
+}
+
+func (m *DirMailer) Send(recipient, templateFile string, data any) error {
+	msg, err := render(m.sender, recipient, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	// e.g. 20250602T090000.000000000-3-user_welcome.eml, which sorts in the
+	// order the emails were sent
+	name := fmt.Sprintf("%s-%d-%s.eml",
+		msg.SentAt.UTC().Format("20060102T150405.000000000"),
+		m.count.Add(1),
+		strings.TrimSuffix(templateFile, filepath.Ext(templateFile)),
+	)
+
+	file, err := os.Create(filepath.Join(m.dir, name))
+	if err != nil {
+		return err
+	}
+
+	_, err = msg.mime().WriteTo(file)
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	return file.Close()
+}
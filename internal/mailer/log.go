@@ -0,0 +1,38 @@
+// Filename: internal/mailer/log.go
+package mailer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Writes the plain text of each email to w, usually stdout, instead of
+// sending it. For running the API locally.
+type LogMailer struct {
+	mu     sync.Mutex
+	w      io.Writer
+	sender string
+}
+
+func NewLog(w io.Writer, sender string) *LogMailer {
+	return &LogMailer{
+		w:      w,
+		sender: sender,
+	}
+}
+
+func (m *LogMailer) Send(recipient, templateFile string, data any) error {
+	msg, err := render(m.sender, recipient, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, err = fmt.Fprintf(m.w, "---- email ----\nTo: %s\nFrom: %s\nSubject: %s\n\n%s\n---------------\n",
+		msg.To, msg.From, msg.Subject, strings.TrimSpace(msg.PlainBody))
+	return err
+}
@@ -15,72 +15,72 @@ import (
 //go:embed templates/*
 var templateFS embed.FS // embed the files from templates into our program
 
-type Mailer struct {
-	dialer *mail.Dialer // connection to the SMTP server
-	sender string       // who is sending the email
+// Sends emails built from the templates. Which transport is used is picked
+// at start up with the -mail-transport flag.
+type Mailer interface {
+	// Send the email to the user. The data parameter is for the dynamic data
+	// to inject into the template
+	Send(recipient, templateFile string, data any) error
 }
 
-// Configure a SMTP connection instance using our credentials from Mailtrap
-func New(host string, port int, username, password, sender string) Mailer {
-	dialer := mail.NewDialer(host, port, username, password)
-	dialer.Timeout = 5 * time.Second
-
-	return Mailer{
-		dialer: dialer,
-		sender: sender,
-	}
+// An email after its template has been filled in
+type Message struct {
+	To        string
+	From      string
+	Subject   string
+	PlainBody string
+	HTMLBody  string
+	Template  string
+	SentAt    time.Time
 }
 
-// Send the email to the user. The data parameter is for the dynamic data
-// to inject into the template
-func (m Mailer) Send(recipient, templateFile string, data any) error {
+// Fill in the subject, plain and html parts of a template
+func render(sender, recipient, templateFile string, data any) (*Message, error) {
 	tmpl, err := template.New("email").ParseFS(templateFS, "templates/"+templateFile)
-
 	if err != nil {
-		return err
+		return nil, err
 	}
-	println("Template parsed successfully", templateFile)
 
 	// fill in the subject part
 	subject := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(subject, "subject", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// fill in the plainBody part
 	plainBody := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(plainBody, "plainBody", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// fill in the htmlBody part
 	htmlBody := new(bytes.Buffer)
 	err = tmpl.ExecuteTemplate(htmlBody, "htmlBody", data)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Craft the message from the parts above
-	msg := mail.NewMessage()
-	msg.SetHeader("To", recipient)
-	msg.SetHeader("From", m.sender)
-	msg.SetHeader("Subject", subject.String())
-	msg.SetBody("text/plain", plainBody.String())
-	msg.AddAlternative("text/html", htmlBody.String())
-
-	// send the message. Try to do this at most 3 times before giving up
-	for i := 1; i <= 3; i++ {
-		err = m.dialer.DialAndSend(msg)
-		// If everything worked, return nil.
-		if err == nil {
-			return nil
-		}
-
-		// If it didn't work, sleep for a short time and retry.
-		time.Sleep(500 * time.Millisecond)
-	}
+	return &Message{
+		To:        recipient,
+		From:      sender,
+		Subject:   subject.String(),
+		PlainBody: plainBody.String(),
+		HTMLBody:  htmlBody.String(),
+		Template:  templateFile,
+		SentAt:    time.Now(),
+	}, nil
+}
 
-	return err // give up
+// Craft the MIME message from the parts above
+func (m *Message) mime() *mail.Message {
+	msg := mail.NewMessage()
+	msg.SetHeader("To", m.To)
+	msg.SetHeader("From", m.From)
+	msg.SetHeader("Subject", m.Subject)
+	msg.SetDateHeader("Date", m.SentAt)
+	msg.SetBody("text/plain", m.PlainBody)
+	msg.AddAlternative("text/html", m.HTMLBody)
+	return msg
 }
@@ -0,0 +1,45 @@
+// Filename: internal/mailer/memory.go
+package mailer
+
+import "sync"
+
+// Keeps every email in memory instead of sending it so tests can check
+// what would have gone out
+type MemoryMailer struct {
+	mu       sync.Mutex
+	sender   string
+	messages []Message
+}
+
+func NewMemory(sender string) *MemoryMailer {
+	return &MemoryMailer{sender: sender}
+}
+
+func (m *MemoryMailer) Send(recipient, templateFile string, data any) error {
+	msg, err := render(m.sender, recipient, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, *msg)
+	return nil
+}
+
+// A copy of the emails sent so far, oldest first
+func (m *MemoryMailer) Messages() []Message {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]Message(nil), m.messages...)
+}
+
+// Forget the emails sent so far
+func (m *MemoryMailer) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = nil
+}
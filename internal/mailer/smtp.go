@@ -0,0 +1,36 @@
+// Filename: internal/mailer/smtp.go
+package mailer
+
+import (
+	"time"
+
+	"github.com/go-mail/mail/v2"
+)
+
+// Sends emails through an SMTP server
+type SMTPMailer struct {
+	dialer *mail.Dialer // connection to the SMTP server
+	sender string       // who is sending the email
+}
+
+// Configure a SMTP connection instance using our credentials
+func NewSMTP(host string, port int, username, password, sender string) *SMTPMailer {
+	dialer := mail.NewDialer(host, port, username, password)
+	dialer.Timeout = 5 * time.Second
+
+	return &SMTPMailer{
+		dialer: dialer,
+		sender: sender,
+	}
+}
+
+func (m *SMTPMailer) Send(recipient, templateFile string, data any) error {
+	msg, err := render(m.sender, recipient, templateFile, data)
+	if err != nil {
+		return err
+	}
+
+	// Failed emails are retried by the outbox so there is only one attempt
+	// here
+	return m.dialer.DialAndSend(msg.mime())
+}
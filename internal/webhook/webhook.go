@@ -0,0 +1,94 @@
+// Filename: internal/webhook/webhook.go
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Headers sent with every delivery. The signature is "sha256=" followed by
+// the hex HMAC-SHA256, keyed with the subscription's secret, of the
+// timestamp header, a full stop and the body.
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderID        = "X-Webhook-ID"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// The JSON body of a delivery. DedupKey is the same every time an event
+// about the same change is sent, so receivers can ignore repeats.
+type Event struct {
+	ID        int64           `json:"id"`
+	Event     string          `json:"event"`
+	DedupKey  string          `json:"dedup_key,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// Sign a body sent at timestamp (Unix seconds) with secret
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Posts events to subscribers
+type Client struct {
+	http *http.Client
+}
+
+// Requests that take longer than timeout are given up on
+func New(timeout time.Duration) *Client {
+	return &Client{
+		http: &http.Client{Timeout: timeout},
+	}
+}
+
+// Post event to url signed with secret. Anything other than a 2xx response
+// is an error. The status code is returned whenever there was a response.
+func (c *Client) Send(ctx context.Context, url, secret string, event *Event) (int, error) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "National-Inservice-Training-Database-Webhooks")
+	req.Header.Set(HeaderEvent, event.Event)
+	req.Header.Set(HeaderID, strconv.FormatInt(event.ID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+
+	res, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+
+	// read a little of the body so the connection can be reused
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64<<10))
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return res.StatusCode, fmt.Errorf("unexpected response status %d", res.StatusCode)
+	}
+
+	return res.StatusCode, nil
+}
@@ -0,0 +1,2 @@
+DELETE FROM permissions
+WHERE code IN ('region:read', 'region:write', 'formation:read', 'formation:write', 'rank:read', 'rank:write', 'posting:read', 'posting:write');
@@ -0,0 +1,10 @@
+INSERT INTO permissions (code)
+VALUES
+   ('region:read'),
+   ('region:write'),
+   ('formation:read'),
+   ('formation:write'),
+   ('rank:read'),
+   ('rank:write'),
+   ('posting:read'),
+   ('posting:write');
@@ -0,0 +1,11 @@
+DROP INDEX IF EXISTS session_starts_at_idx;
+
+ALTER TABLE session
+DROP CONSTRAINT IF EXISTS session_status_check,
+DROP CONSTRAINT IF EXISTS session_capacity_check,
+DROP CONSTRAINT IF EXISTS session_dates_check,
+DROP COLUMN IF EXISTS status,
+DROP COLUMN IF EXISTS capacity,
+DROP COLUMN IF EXISTS venue,
+DROP COLUMN IF EXISTS ends_at,
+DROP COLUMN IF EXISTS starts_at;
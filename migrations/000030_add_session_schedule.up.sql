@@ -0,0 +1,19 @@
+ALTER TABLE session
+ADD COLUMN starts_at timestamp(0) WITH TIME ZONE,
+ADD COLUMN ends_at timestamp(0) WITH TIME ZONE,
+ADD COLUMN venue text NOT NULL DEFAULT '',
+ADD COLUMN capacity integer NOT NULL DEFAULT 0,
+ADD COLUMN status text NOT NULL DEFAULT 'planned';
+
+-- Existing sessions have no schedule, fall back to when they were created
+UPDATE session SET starts_at = created_at, ends_at = created_at;
+
+ALTER TABLE session
+ALTER COLUMN starts_at SET NOT NULL,
+ALTER COLUMN ends_at SET NOT NULL;
+
+ALTER TABLE session ADD CONSTRAINT session_dates_check CHECK (ends_at >= starts_at);
+ALTER TABLE session ADD CONSTRAINT session_capacity_check CHECK (capacity >= 0);
+ALTER TABLE session ADD CONSTRAINT session_status_check CHECK (status IN ('planned', 'ongoing', 'completed', 'cancelled'));
+
+CREATE INDEX IF NOT EXISTS session_starts_at_idx ON session (starts_at);
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS session_waitlist (
+  id bigserial PRIMARY KEY,
+  session_id bigint NOT NULL REFERENCES session(id) ON DELETE CASCADE,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (session_id, user_id)
+);
@@ -0,0 +1 @@
+ALTER TABLE attendance DROP CONSTRAINT IF EXISTS attendance_user_session_date_key;
@@ -0,0 +1,8 @@
+-- Keep only the latest record where a day was entered more than once
+DELETE FROM attendance a
+USING attendance b
+WHERE a.user_session_id = b.user_session_id
+  AND a.date = b.date
+  AND a.id < b.id;
+
+ALTER TABLE attendance ADD CONSTRAINT attendance_user_session_date_key UNIQUE (user_session_id, date);
@@ -0,0 +1,11 @@
+ALTER TABLE user_session
+DROP COLUMN IF EXISTS overridden_at,
+DROP COLUMN IF EXISTS overridden_by,
+DROP COLUMN IF EXISTS override_reason,
+DROP COLUMN IF EXISTS completed;
+
+ALTER TABLE course
+DROP CONSTRAINT IF EXISTS course_hours_per_day_check,
+DROP CONSTRAINT IF EXISTS course_min_attendance_percent_check,
+DROP COLUMN IF EXISTS hours_per_day,
+DROP COLUMN IF EXISTS min_attendance_percent;
@@ -0,0 +1,32 @@
+-- Completion policy for each course. Trainees earn hours_per_day for every
+-- day they attend and complete the course once their attendance reaches
+-- min_attendance_percent.
+ALTER TABLE course
+ADD COLUMN min_attendance_percent integer NOT NULL DEFAULT 80,
+ADD COLUMN hours_per_day integer NOT NULL DEFAULT 0;
+
+ALTER TABLE course ADD CONSTRAINT course_min_attendance_percent_check CHECK (min_attendance_percent BETWEEN 0 AND 100);
+ALTER TABLE course ADD CONSTRAINT course_hours_per_day_check CHECK (hours_per_day >= 0);
+
+-- A user session with an override_reason keeps the hours and completion
+-- entered by hand instead of having them worked out from attendance
+ALTER TABLE user_session
+ADD COLUMN completed bool NOT NULL DEFAULT false,
+ADD COLUMN override_reason text,
+ADD COLUMN overridden_by bigint REFERENCES users(id) ON DELETE SET NULL,
+ADD COLUMN overridden_at timestamp(0) WITH TIME ZONE;
+
+-- Hours typed in before this change are kept as overrides. They only count
+-- as completing the course when they reach the hours the course requires
+-- for the officer's posting; anything less stays incomplete.
+UPDATE user_session us
+SET completed = COALESCE(us.credithours_completed >= (
+        SELECT MAX(cp.credithours)
+        FROM session s
+        INNER JOIN users u ON u.id = us.trainee_id
+        INNER JOIN course_posting cp ON cp.course_id = s.course_id AND cp.posting_id = u.posting_id
+        WHERE s.id = us.session_id
+    ), false),
+    override_reason = 'recorded before attendance-derived credit hours',
+    overridden_at = NOW()
+WHERE us.credithours_completed > 0;
@@ -0,0 +1,46 @@
+CREATE TABLE IF NOT EXISTS role_permissions (
+  role_id bigint NOT NULL REFERENCES role(id) ON DELETE CASCADE,
+  permission_id bigint NOT NULL REFERENCES permissions(id) ON DELETE CASCADE,
+  PRIMARY KEY (role_id, permission_id)
+);
+
+-- Administrators can do everything
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r CROSS JOIN permissions p
+WHERE r.role = 'Administrator'
+ON CONFLICT DO NOTHING;
+
+-- Facilitators run sessions, take the roll and record results
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r CROSS JOIN permissions p
+WHERE r.role = 'Facilitator'
+  AND p.code = ANY(ARRAY[
+    'users:read', 'course:read', 'course_posting:read',
+    'session:read', 'session:write', 'user_session:read', 'user_session:write',
+    'attendance:read', 'attendance:write', 'facilitator_rating:read',
+    'region:read', 'formation:read', 'rank:read', 'posting:read'
+  ])
+ON CONFLICT DO NOTHING;
+
+-- Officers browse the training on offer and the requirements for their posting
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r CROSS JOIN permissions p
+WHERE r.role = 'Officer'
+  AND p.code = ANY(ARRAY[
+    'course:read', 'course_posting:read', 'session:read',
+    'region:read', 'formation:read', 'rank:read', 'posting:read'
+  ])
+ON CONFLICT DO NOTHING;
+
+-- Trainees see their courses and sessions and rate their facilitators
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r CROSS JOIN permissions p
+WHERE r.role = 'Trainee'
+  AND p.code = ANY(ARRAY[
+    'course:read', 'session:read', 'facilitator_rating:write'
+  ])
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,9 @@
+ALTER TABLE users_role
+DROP CONSTRAINT IF EXISTS users_role_scope_check,
+DROP COLUMN IF EXISTS formation_id,
+DROP COLUMN IF EXISTS region_id;
+
+ALTER TABLE users_permissions
+DROP CONSTRAINT IF EXISTS users_permissions_scope_check,
+DROP COLUMN IF EXISTS formation_id,
+DROP COLUMN IF EXISTS region_id;
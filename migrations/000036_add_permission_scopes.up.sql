@@ -0,0 +1,15 @@
+-- Permission grants and role assignments can be limited to a region or a
+-- single formation. Leaving both columns NULL keeps the grant national.
+-- Grants are removed along with their region or formation rather than
+-- falling back to national.
+ALTER TABLE users_permissions
+ADD COLUMN region_id bigint REFERENCES region(id) ON DELETE CASCADE,
+ADD COLUMN formation_id bigint REFERENCES formation(id) ON DELETE CASCADE;
+
+ALTER TABLE users_permissions ADD CONSTRAINT users_permissions_scope_check CHECK (region_id IS NULL OR formation_id IS NULL);
+
+ALTER TABLE users_role
+ADD COLUMN region_id bigint REFERENCES region(id) ON DELETE CASCADE,
+ADD COLUMN formation_id bigint REFERENCES formation(id) ON DELETE CASCADE;
+
+ALTER TABLE users_role ADD CONSTRAINT users_role_scope_check CHECK (region_id IS NULL OR formation_id IS NULL);
@@ -0,0 +1,17 @@
+-- Who changed what. changes holds the before and after value of every
+-- field that changed; actor_id is NULL for changes made without signing
+-- in, such as registering or activating an account.
+CREATE TABLE IF NOT EXISTS audit_log (
+  id bigserial PRIMARY KEY,
+  actor_id bigint REFERENCES users(id) ON DELETE SET NULL,
+  action text NOT NULL,
+  entity_type text NOT NULL,
+  entity_id bigint NOT NULL DEFAULT 0,
+  changes jsonb NOT NULL DEFAULT '{}',
+  ip_address text NOT NULL DEFAULT '',
+  created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS audit_log_actor_id_idx ON audit_log (actor_id);
+CREATE INDEX IF NOT EXISTS audit_log_entity_idx ON audit_log (entity_type, entity_id);
+CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS session_deleted_at_idx;
+DROP INDEX IF EXISTS course_deleted_at_idx;
+DROP INDEX IF EXISTS users_deleted_at_idx;
+
+ALTER TABLE session DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE course DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE users DROP COLUMN IF EXISTS deleted_at;
@@ -0,0 +1,10 @@
+-- Deleting a user, course or session only marks it as deleted so their
+-- training history survives. Rows are removed for good by the purge
+-- endpoint once they have been deleted for longer than the retention period.
+ALTER TABLE users ADD COLUMN deleted_at timestamp(0) WITH TIME ZONE;
+ALTER TABLE course ADD COLUMN deleted_at timestamp(0) WITH TIME ZONE;
+ALTER TABLE session ADD COLUMN deleted_at timestamp(0) WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS users_deleted_at_idx ON users (deleted_at);
+CREATE INDEX IF NOT EXISTS course_deleted_at_idx ON course (deleted_at);
+CREATE INDEX IF NOT EXISTS session_deleted_at_idx ON session (deleted_at);
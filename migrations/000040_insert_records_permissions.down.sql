@@ -0,0 +1,2 @@
+DELETE FROM permissions
+WHERE code IN ('records:admin');
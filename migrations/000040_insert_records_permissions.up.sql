@@ -0,0 +1,10 @@
+INSERT INTO permissions (code)
+VALUES
+   ('records:admin');
+
+-- Administrators hold every permission
+INSERT INTO role_permissions (role_id, permission_id)
+SELECT r.id, p.id
+FROM role r CROSS JOIN permissions p
+WHERE r.role = 'Administrator' AND p.code = 'records:admin'
+ON CONFLICT DO NOTHING;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS facilitator_rating_user_id_idx;
+DROP INDEX IF EXISTS facilitator_rating_session_rater_idx;
+
+ALTER TABLE facilitator_rating
+DROP COLUMN IF EXISTS comment,
+DROP COLUMN IF EXISTS rater_id,
+DROP COLUMN IF EXISTS session_id;
@@ -0,0 +1,10 @@
+-- Ratings are given by a trainee for the facilitator of a session they took
+-- part in, once per session. Older ratings were not linked to a session or
+-- rater so both columns stay nullable.
+ALTER TABLE facilitator_rating
+ADD COLUMN session_id bigint REFERENCES session(id) ON DELETE CASCADE,
+ADD COLUMN rater_id bigint REFERENCES users(id) ON DELETE SET NULL,
+ADD COLUMN comment text NOT NULL DEFAULT '';
+
+CREATE UNIQUE INDEX IF NOT EXISTS facilitator_rating_session_rater_idx ON facilitator_rating (session_id, rater_id);
+CREATE INDEX IF NOT EXISTS facilitator_rating_user_id_idx ON facilitator_rating (user_id);
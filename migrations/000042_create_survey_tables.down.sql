@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS survey_submission;
+DROP TABLE IF EXISTS survey_answer;
+DROP TABLE IF EXISTS survey_response;
+DROP TABLE IF EXISTS survey_question;
+DROP TABLE IF EXISTS survey;
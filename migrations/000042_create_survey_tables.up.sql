@@ -0,0 +1,46 @@
+-- End-of-course evaluation. Each course can have one survey whose questions
+-- are answered by trainees after a session.
+CREATE TABLE IF NOT EXISTS survey (
+  id bigserial PRIMARY KEY,
+  course_id bigint NOT NULL UNIQUE REFERENCES course(id) ON DELETE CASCADE,
+  title text NOT NULL,
+  created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- kind is likert (a score from 1 to 5), choice (one of options) or text
+CREATE TABLE IF NOT EXISTS survey_question (
+  id bigserial PRIMARY KEY,
+  survey_id bigint NOT NULL REFERENCES survey(id) ON DELETE CASCADE,
+  position int NOT NULL,
+  kind text NOT NULL CHECK (kind IN ('likert', 'choice', 'text')),
+  prompt text NOT NULL,
+  options text[] NOT NULL DEFAULT '{}',
+  required boolean NOT NULL DEFAULT true,
+  UNIQUE (survey_id, position)
+);
+
+-- respondent_id is NULL for anonymous responses
+CREATE TABLE IF NOT EXISTS survey_response (
+  id bigserial PRIMARY KEY,
+  survey_id bigint NOT NULL REFERENCES survey(id) ON DELETE CASCADE,
+  session_id bigint NOT NULL REFERENCES session(id) ON DELETE CASCADE,
+  respondent_id bigint REFERENCES users(id) ON DELETE SET NULL,
+  created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS survey_response_session_id_idx ON survey_response (session_id);
+
+CREATE TABLE IF NOT EXISTS survey_answer (
+  response_id bigint NOT NULL REFERENCES survey_response(id) ON DELETE CASCADE,
+  question_id bigint NOT NULL REFERENCES survey_question(id) ON DELETE CASCADE,
+  value text NOT NULL,
+  PRIMARY KEY (response_id, question_id)
+);
+
+-- Who has already responded for a session. Kept apart from survey_response
+-- so that anonymous responses can't be traced back to the trainee.
+CREATE TABLE IF NOT EXISTS survey_submission (
+  session_id bigint NOT NULL REFERENCES session(id) ON DELETE CASCADE,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  PRIMARY KEY (session_id, user_id)
+);
@@ -0,0 +1,15 @@
+-- A prerequisite is either another course the trainee must have completed
+-- or the most junior rank allowed on the course, never both. A course can
+-- only have one minimum rank.
+CREATE TABLE IF NOT EXISTS course_prerequisite (
+  id bigserial PRIMARY KEY,
+  course_id bigint NOT NULL REFERENCES course(id) ON DELETE CASCADE,
+  required_course_id bigint REFERENCES course(id) ON DELETE CASCADE,
+  min_rank_id bigint REFERENCES rank(id) ON DELETE CASCADE,
+  created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  CHECK ((required_course_id IS NULL) <> (min_rank_id IS NULL)),
+  CHECK (required_course_id <> course_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS course_prerequisite_course_idx ON course_prerequisite (course_id, required_course_id) WHERE required_course_id IS NOT NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS course_prerequisite_rank_idx ON course_prerequisite (course_id) WHERE min_rank_id IS NOT NULL;
@@ -0,0 +1,4 @@
+ALTER TABLE course DROP CONSTRAINT IF EXISTS course_validity_months_check;
+
+ALTER TABLE course
+DROP COLUMN IF EXISTS validity_months;
@@ -0,0 +1,7 @@
+-- Completions of courses with a validity period, such as firearms
+-- qualification, lapse that many months after the session ends. 0 means
+-- a completion never expires.
+ALTER TABLE course
+ADD COLUMN validity_months integer NOT NULL DEFAULT 0;
+
+ALTER TABLE course ADD CONSTRAINT course_validity_months_check CHECK (validity_months >= 0);
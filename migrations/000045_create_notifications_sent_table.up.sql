@@ -0,0 +1,11 @@
+-- One row per reminder email so that scheduled runs, including runs after a
+-- restart, never send the same reminder twice. reference_id is the session
+-- for session reminders and the completed user_session for expiry notices.
+CREATE TABLE IF NOT EXISTS notifications_sent (
+  id bigserial PRIMARY KEY,
+  kind text NOT NULL,
+  user_id bigint NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+  reference_id bigint NOT NULL,
+  sent_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  UNIQUE (kind, user_id, reference_id)
+);
@@ -0,0 +1,20 @@
+-- Emails are written here in the same transaction as the change that
+-- triggers them and sent by a background worker. Messages that keep failing
+-- end up 'failed' until an admin resends them. data holds the template
+-- values and is cleared once the email is sent since it can carry tokens.
+CREATE TABLE IF NOT EXISTS email_outbox (
+  id bigserial PRIMARY KEY,
+  recipient text NOT NULL,
+  template text NOT NULL,
+  data jsonb NOT NULL DEFAULT '{}',
+  status text NOT NULL DEFAULT 'pending',
+  attempts integer NOT NULL DEFAULT 0,
+  last_error text NOT NULL DEFAULT '',
+  next_attempt_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  sent_at timestamp(0) WITH TIME ZONE,
+  created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  CHECK (status IN ('pending', 'sent', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS email_outbox_due_idx ON email_outbox (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS email_outbox_status_idx ON email_outbox (status);
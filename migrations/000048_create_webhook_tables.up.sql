@@ -0,0 +1,33 @@
+-- Other systems subscribe to training events by URL. The secret signs every
+-- delivery so receivers can check it came from us.
+CREATE TABLE IF NOT EXISTS webhook_subscriptions (
+  id bigserial PRIMARY KEY,
+  url text NOT NULL,
+  secret text NOT NULL,
+  events text[] NOT NULL,
+  description text NOT NULL DEFAULT '',
+  active boolean NOT NULL DEFAULT true,
+  created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  CHECK (cardinality(events) > 0)
+);
+
+-- Each event is queued here for every subscription that wants it, in the
+-- same transaction as the change where possible, and sent by a background
+-- worker. The rows double as the delivery log.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+  id bigserial PRIMARY KEY,
+  subscription_id bigint NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+  event text NOT NULL,
+  payload jsonb NOT NULL DEFAULT '{}',
+  status text NOT NULL DEFAULT 'pending',
+  attempts integer NOT NULL DEFAULT 0,
+  last_status_code integer NOT NULL DEFAULT 0,
+  last_error text NOT NULL DEFAULT '',
+  next_attempt_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  delivered_at timestamp(0) WITH TIME ZONE,
+  created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW(),
+  CHECK (status IN ('pending', 'delivered', 'failed'))
+);
+
+CREATE INDEX IF NOT EXISTS webhook_deliveries_due_idx ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS webhook_deliveries_subscription_idx ON webhook_deliveries (subscription_id, created_at);
@@ -0,0 +1,5 @@
+ALTER TABLE user_session DROP COLUMN IF EXISTS completion_notified;
+
+DROP INDEX IF EXISTS webhook_deliveries_dedup_key_idx;
+
+ALTER TABLE webhook_deliveries DROP COLUMN IF EXISTS dedup_key;
@@ -0,0 +1,12 @@
+-- A key that identifies what a delivery is about, such as one change to one
+-- user session, so the same event is never queued twice for a subscription.
+-- It is sent to receivers so they can ignore repeats too.
+ALTER TABLE webhook_deliveries ADD COLUMN dedup_key text;
+
+CREATE UNIQUE INDEX IF NOT EXISTS webhook_deliveries_dedup_key_idx ON webhook_deliveries (subscription_id, dedup_key);
+
+-- Whether subscribers were last told the user session was completed or not,
+-- so completion and revocation events are only sent when it really changes
+ALTER TABLE user_session ADD COLUMN completion_notified boolean NOT NULL DEFAULT false;
+
+UPDATE user_session SET completion_notified = completed;
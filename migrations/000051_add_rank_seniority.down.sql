@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS rank_seniority_idx;
+
+ALTER TABLE rank DROP COLUMN IF EXISTS seniority;
@@ -0,0 +1,25 @@
+-- How senior a rank is, higher being more senior, so minimum rank
+-- prerequisites don't depend on the order ranks happened to be inserted in.
+-- Ranks of the same level can share a seniority.
+ALTER TABLE rank ADD COLUMN seniority integer NOT NULL DEFAULT 0;
+
+UPDATE rank r
+SET seniority = s.seniority
+FROM (VALUES
+   ('Special Constable - SC', 1),
+   ('Constable - PC', 2),
+   ('Corporal - CPL', 3),
+   ('Sergeant - SGT', 4),
+   ('Inspector of Police - INSP', 5),
+   ('Assistant Superintendent of Police - ASP', 6),
+   ('Superintendent of Police - SUPT', 7),
+   ('Senior Superintendent of Police - Sr. SUPT', 8),
+   ('Assistant Commissioner of Police - ACP', 9),
+   ('Deputy Commissioner of Police - DCP', 10),
+   ('Commissioner of Police - COMPOL', 11)
+) AS s(title, seniority)
+WHERE r.title = s.title;
+
+ALTER TABLE rank ALTER COLUMN seniority DROP DEFAULT;
+
+CREATE INDEX IF NOT EXISTS rank_seniority_idx ON rank (seniority);
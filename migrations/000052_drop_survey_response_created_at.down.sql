@@ -0,0 +1 @@
+ALTER TABLE survey_response ADD COLUMN IF NOT EXISTS created_at timestamp(0) WITH TIME ZONE NOT NULL DEFAULT NOW();
@@ -0,0 +1,4 @@
+-- When a response was saved could be matched against when a trainee was
+-- signed in, so anonymous responses could be traced back to them. Nothing
+-- needs the time, so it isn't kept for any response.
+ALTER TABLE survey_response DROP COLUMN IF EXISTS created_at;